@@ -0,0 +1,153 @@
+package form
+
+import (
+	"reflect"
+	"strings"
+)
+
+//fieldKind 字段在绑定和校验时的处理方式
+type fieldKind int
+
+const (
+	//plainField 普通字段，包括slice、指针、time.Time以及isCustomType的类型
+	plainField fieldKind = iota
+	//nestedField 逐个字段处理的嵌套struct
+	nestedField
+	//structPtrField 指向嵌套struct的指针
+	structPtrField
+	//structSliceField 元素为嵌套struct的slice
+	structSliceField
+	//fileField 上传文件
+	fileField
+)
+
+//fieldMeta 从字段的tag中解析出的信息
+type fieldMeta struct {
+	index int
+	field reflect.StructField
+	kind  fieldKind
+	//name 字段的key，不含前缀
+	name  string
+	title string
+	//multi 是否为需要读取多个值的slice字段
+	multi        bool
+	defaultValue string
+	rules        []rule
+	messages     map[string]string
+	sources      []string
+	sep          string
+	quoted       bool
+}
+
+//structMeta struct中需要绑定和校验的字段，跳过的字段不在其中
+type structMeta struct {
+	fields []*fieldMeta
+	byName map[string]*fieldMeta
+}
+
+//structMeta 返回struct t的字段信息，第一次使用时解析并缓存，可以并发调用。
+//缓存建立后再修改Form中与tag有关的设置以及注册转换函数不会生效
+func (f *Form) structMeta(t reflect.Type) *structMeta {
+	if m, ok := f.cache.Load(t); ok {
+		return m.(*structMeta)
+	}
+	m := &structMeta{
+		fields: make([]*fieldMeta, 0, t.NumField()),
+		byName: make(map[string]*fieldMeta, t.NumField()),
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if f.skipField(field) {
+			continue
+		}
+		fm := f.newFieldMeta(field)
+		fm.index = i
+		m.fields = append(m.fields, fm)
+		m.byName[field.Name] = fm
+	}
+	actual, _ := f.cache.LoadOrStore(t, m)
+	return actual.(*structMeta)
+}
+
+//fieldMeta 返回struct t中名为name的字段的信息，包括嵌入struct中的字段
+func (f *Form) fieldMeta(t reflect.Type, name string) (*fieldMeta, bool) {
+	if fm, ok := f.structMeta(t).byName[name]; ok {
+		return fm, true
+	}
+	sf, ok := t.FieldByName(name)
+	if !ok {
+		return nil, false
+	}
+	return f.newFieldMeta(sf), true
+}
+
+func (f *Form) newFieldMeta(field reflect.StructField) *fieldMeta {
+	fm := &fieldMeta{
+		field:        field,
+		name:         defaultField(field, f.FormFields),
+		title:        defaultField(field, f.LabelFields),
+		defaultValue: field.Tag.Get(f.DefaultField),
+		rules:        f.parseRules(field),
+		messages:     f.parseMessages(field),
+		sources:      f.sources(field),
+		sep:          field.Tag.Get(f.SepField),
+		quoted:       f.quoted(field),
+	}
+	switch {
+	case f.isNestedStruct(field.Type):
+		fm.kind = nestedField
+	case isFileType(field.Type):
+		fm.kind = fileField
+	case f.isStructPtr(field.Type):
+		fm.kind = structPtrField
+	case f.isStructSlice(field.Type):
+		fm.kind = structSliceField
+	}
+	fm.multi = fm.kind == plainField && field.Type.Kind() == reflect.Slice && !f.isCustomType(field.Type)
+	f.parseLimits(fm)
+	return fm
+}
+
+//parseLimits 预先按字段的类型解析min、max、range的参数，校验时不用每次都解析
+func (f *Form) parseLimits(fm *fieldMeta) {
+	field := fm.field
+	if field.Type.Kind() == reflect.Ptr && fm.kind != fileField {
+		//与checkField一致，检测器看到的是指针指向的类型
+		field.Type = field.Type.Elem()
+	}
+	for i, r := range fm.rules {
+		switch r.Name {
+		case "min", "max", "range":
+			limits := make([]limit, len(r.Params))
+			for j, param := range r.Params {
+				limits[j] = f.parseLimit(field, param)
+			}
+			fm.rules[i].limits = limits
+		}
+	}
+}
+
+//parseMessages 解析MessageField
+func (f *Form) parseMessages(t reflect.StructField) map[string]string {
+	return parseMessageTag(t.Tag.Get(f.MessageField))
+}
+
+//parseMessageTag 解析自定义错误信息，格式为"规则名=错误信息;规则名=错误信息"
+func parseMessageTag(tag string) map[string]string {
+	if tag == "" {
+		return nil
+	}
+	messages := make(map[string]string)
+	for _, item := range strings.Split(tag, ";") {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if rule := strings.TrimSpace(kv[0]); rule != "" {
+			if _, ok := messages[rule]; !ok {
+				messages[rule] = kv[1]
+			}
+		}
+	}
+	return messages
+}
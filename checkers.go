@@ -2,13 +2,28 @@ package form
 
 import (
 	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/labstack/echo/v4"
 )
 
-var checkers map[string]CheckFunc
+var (
+	checkersMu sync.RWMutex
+	//checkers 全局的检测器，所有Form共用
+	checkers map[string]CheckFunc
+	//builtinCheckers 内置检测器的函数地址，用于判断规则是否被覆盖
+	builtinCheckers map[string]uintptr
+	//builtinOverridden 不为0时有内置检测器被AddCheckFunc覆盖，生成的校验代码不再使用
+	builtinOverridden int32
+)
 
 func init() {
 	checkers = map[string]CheckFunc{
@@ -30,28 +45,107 @@ func init() {
 		"tel":          Tel,
 		"phone":        Phone,
 		"idcard":       IDCard,
+		"eqfield":      EqField,
+		"nefield":      NeField,
+		"gtfield":      GtField,
+		"gtefield":     GteField,
+		"ltfield":      LtField,
+		"ltefield":     LteField,
+
+		"required_if":      RequiredIf,
+		"required_unless":  RequiredUnless,
+		"required_with":    RequiredWith,
+		"required_without": RequiredWithout,
+
+		"filesize": FileSize,
+		"mimetype": MimeType,
+		"ext":      Ext,
+	}
+	builtinCheckers = make(map[string]uintptr, len(checkers))
+	for name, c := range checkers {
+		builtinCheckers[name] = reflect.ValueOf(c).Pointer()
 	}
 }
 
-//AddCheckFunc add CheckFunc
+//AddCheckFunc 注册全局的检测器，所有Form都可以使用，可以并发调用
 func AddCheckFunc(name string, c CheckFunc) {
+	checkersMu.Lock()
 	checkers[name] = c
+	checkersMu.Unlock()
+	if isOverride(name, c) {
+		atomic.StoreInt32(&builtinOverridden, 1)
+	}
+}
+
+//RegisterCheck 注册只在当前Form中使用的检测器，同名时覆盖全局的检测器，可以并发调用
+func (f *Form) RegisterCheck(name string, c CheckFunc) {
+	f.checksMu.Lock()
+	if f.checks == nil {
+		f.checks = make(map[string]CheckFunc)
+	}
+	f.checks[name] = c
+	f.checksMu.Unlock()
+	if isOverride(name, c) {
+		atomic.StoreInt32(&f.builtinOverridden, 1)
+	}
+}
+
+//isOverride c是否覆盖了名为name的内置检测器
+func isOverride(name string, c CheckFunc) bool {
+	p, ok := builtinCheckers[name]
+	return ok && reflect.ValueOf(c).Pointer() != p
+}
+
+//checkFunc 返回名为name的检测器，先查找Form注册的，找不到时使用全局的
+func (f *Form) checkFunc(name string) (CheckFunc, bool) {
+	f.checksMu.RLock()
+	c, ok := f.checks[name]
+	f.checksMu.RUnlock()
+	if ok {
+		return c, true
+	}
+	checkersMu.RLock()
+	c, ok = checkers[name]
+	checkersMu.RUnlock()
+	return c, ok
 }
 
 //Context context
 type Context struct {
-	//Input 表单值
+	//Input 表单值，slice为以,连接的所有值，struct slice为元素个数
 	Input string
+	//Key 表单中的key，嵌套struct中的字段包含前缀
+	Key string
 	//Title 显示的标题
 	Title string
 	//Field 字段Type
 	Field reflect.StructField
 	//Value 字段Value
 	Value reflect.Value
+	//Parent 字段所在的struct，用于读取同级的其它字段
+	Parent reflect.Value
 	//Params 检测器参数
 	Params []string
-	//Ctx echo的context
+	//Source 读取值的来源，CheckValue模式下可能为nil
+	Source ValueSource
+	//Ctx echo的context，来源不是echo时为nil
 	Ctx echo.Context
+
+	form   *Form
+	meta   *fieldMeta
+	prefix string
+	//limits 缓存中解析好的参数，与Params一一对应，没有时检测器自己解析
+	limits []limit
+	//zero CheckValue模式下字段为数值的零值，Input为"0"，required仍视为空
+	zero bool
+}
+
+//Fail 返回校验失败的错误，错误信息为消息目录中key对应的模板按请求语言翻译后的结果。
+//模板的第一个参数为字段标题，args依次作为之后的参数
+func (c Context) Fail(key string, args ...interface{}) error {
+	return &FieldError{
+		Message: c.formOrDefault().translate(c.Source, key, append([]interface{}{c.Title}, args...)...),
+	}
 }
 
 //CheckFunc 检测函数
@@ -59,8 +153,62 @@ type CheckFunc func(c Context) error
 
 //Required required
 func Required(c Context) error {
-	if !IsRequired(c.Input) {
-		return fmt.Errorf("%s不能为空", c.Title)
+	if !IsRequired(c.Input) || c.zero {
+		return c.Fail("required")
+	}
+	return nil
+}
+
+//RequiredIf 指定字段的值为某几个值之一时不能为空，如required_if:NeedInvoice,1
+func RequiredIf(c Context) error {
+	if len(c.Params) < 2 {
+		return fmt.Errorf("参数错误")
+	}
+	other := c.otherInput(c.Params[0])
+	for _, v := range c.Params[1:] {
+		if other == v {
+			return Required(c)
+		}
+	}
+	return nil
+}
+
+//RequiredUnless 除非指定字段的值为某几个值之一，否则不能为空
+func RequiredUnless(c Context) error {
+	if len(c.Params) < 2 {
+		return fmt.Errorf("参数错误")
+	}
+	other := c.otherInput(c.Params[0])
+	for _, v := range c.Params[1:] {
+		if other == v {
+			return nil
+		}
+	}
+	return Required(c)
+}
+
+//RequiredWith 指定的字段中任意一个有值时不能为空
+func RequiredWith(c Context) error {
+	if len(c.Params) == 0 {
+		return fmt.Errorf("参数错误")
+	}
+	for _, name := range c.Params {
+		if c.otherInput(name) != "" {
+			return Required(c)
+		}
+	}
+	return nil
+}
+
+//RequiredWithout 指定的字段中任意一个没有值时不能为空
+func RequiredWithout(c Context) error {
+	if len(c.Params) == 0 {
+		return fmt.Errorf("参数错误")
+	}
+	for _, name := range c.Params {
+		if c.otherInput(name) == "" {
+			return Required(c)
+		}
 	}
 	return nil
 }
@@ -74,53 +222,77 @@ func MinOrMax(ctx Context, t string) error {
 		return fmt.Errorf("参数错误")
 	}
 	if IsIntType(ctx.Field) {
-		n, err := strconv.ParseInt(ctx.Params[0], 10, 64)
-		if err != nil {
-			return fmt.Errorf("参数错误:%v", err)
+		l := ctx.limit()
+		if l.err != nil {
+			return fmt.Errorf("参数错误:%v", l.err)
 		}
-		v, err := strconv.ParseInt(ctx.Input, 10, 64)
+		n := l.i
+		parse, rule := ctx.formOrDefault().intParser(ctx.Field)
+		v, err := parse(ctx.Input)
 		if err != nil {
-			return fmt.Errorf("输入的值错误:%v", err)
+			return ctx.Fail(rule)
+		}
+		display := ctx.Params[0]
+		if rule == "integer" {
+			display = strconv.FormatInt(n, 10)
 		}
 		if t == "min" {
 			if v < n {
-				return fmt.Errorf("%s不能小于%d", ctx.Title, n)
+				return ctx.Fail("min", display)
 			}
 		} else {
 			if v > n {
-				return fmt.Errorf("%s不能大于%d", ctx.Title, n)
+				return ctx.Fail("max", display)
 			}
 		}
 	} else if IsFloatType(ctx.Field) {
-		n, err := strconv.ParseFloat(ctx.Params[0], 64)
-		if err != nil {
-			return fmt.Errorf("参数错误:%v", err)
+		l := ctx.limit()
+		if l.err != nil {
+			return fmt.Errorf("参数错误:%v", l.err)
 		}
+		n := l.f
 		v, err := strconv.ParseFloat(ctx.Input, 64)
 		if err != nil {
-			return fmt.Errorf("输入的值错误:%v", err)
+			return ctx.Fail("float")
 		}
 		if t == "min" {
 			if v < n {
-				return fmt.Errorf("%s不能小于%f", ctx.Title, n)
+				return ctx.Fail("min", fmt.Sprintf("%f", n))
 			}
 		} else {
 			if v > n {
-				return fmt.Errorf("%s不能大于%f", ctx.Title, n)
+				return ctx.Fail("max", fmt.Sprintf("%f", n))
 			}
 		}
 	} else if IsStringType(ctx.Field) {
-		n, err := strconv.Atoi(ctx.Params[0])
-		if err != nil {
-			return fmt.Errorf("参数错误:%v", err)
+		l := ctx.limit()
+		if l.err != nil {
+			return fmt.Errorf("参数错误:%v", l.err)
 		}
+		n := l.n
 		if t == "min" {
 			if len(ctx.Input) < n {
-				return fmt.Errorf("%s的长度不能小于%d", ctx.Title, n)
+				return ctx.Fail("min_len", strconv.Itoa(n))
 			}
 		} else {
 			if len(ctx.Input) > n {
-				return fmt.Errorf("%s的长度不能大于%d", ctx.Title, n)
+				return ctx.Fail("max_len", strconv.Itoa(n))
+			}
+		}
+	} else if ctx.Field.Type.Kind() == reflect.Slice {
+		l := ctx.limit()
+		if l.err != nil {
+			return fmt.Errorf("参数错误:%v", l.err)
+		}
+		n := l.n
+		count := ctx.count()
+		if t == "min" {
+			if count < n {
+				return ctx.Fail("min_count", strconv.Itoa(n))
+			}
+		} else {
+			if count > n {
+				return ctx.Fail("max_count", strconv.Itoa(n))
 			}
 		}
 	} else {
@@ -129,6 +301,85 @@ func MinOrMax(ctx Context, t string) error {
 	return nil
 }
 
+//limit min、max、range的一个参数按字段类型解析后的值
+type limit struct {
+	//i 整数字段的参数，time.Duration和unit:"bytes"的字段按各自的格式解析
+	i int64
+	//f 浮点数字段的参数
+	f float64
+	//n 字符串的长度或者slice的元素个数
+	n   int
+	err error
+}
+
+//parseLimit 按字段的类型解析min、max、range的参数，不支持的类型返回零值
+func (f *Form) parseLimit(field reflect.StructField, param string) limit {
+	var l limit
+	switch {
+	case IsIntType(field):
+		parse, _ := f.intParser(field)
+		l.i, l.err = parse(param)
+	case IsFloatType(field):
+		l.f, l.err = strconv.ParseFloat(param, 64)
+	case IsStringType(field), field.Type.Kind() == reflect.Slice:
+		l.n, l.err = strconv.Atoi(param)
+	}
+	return l
+}
+
+//limit 返回第一个参数解析后的值，优先使用缓存中解析好的参数
+func (c Context) limit() limit {
+	if len(c.limits) == len(c.Params) && len(c.limits) > 0 {
+		return c.limits[0]
+	}
+	return c.formOrDefault().parseLimit(c.Field, c.Params[0])
+}
+
+//intParser 返回整数字段解析min、max的参数和输入的函数，以及输入有误时的规则名。
+//time.Duration和unit:"bytes"的字段可以使用带单位的参数，如max:1h、max:100MB
+func (f *Form) intParser(field reflect.StructField) (func(string) (int64, error), string) {
+	if field.Type == durationType {
+		return parseDuration, "duration"
+	}
+	if field.Tag.Get(f.UnitField) == "bytes" {
+		return parseSize, "size"
+	}
+	return func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, 64)
+	}, "integer"
+}
+
+//parseIntUnit 按intParser返回的规则名解析整数字段的输入
+func parseIntUnit(unit, s string) (int64, error) {
+	switch unit {
+	case "duration":
+		return parseDuration(s)
+	case "size":
+		return parseSize(s)
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+//count slice字段的元素个数
+func (c Context) count() int {
+	if c.form != nil && c.form.CheckMode == CheckValue {
+		return c.Value.Len()
+	}
+	if c.formOrDefault().isStructSlice(c.Field.Type) {
+		n, _ := strconv.Atoi(c.Input)
+		return n
+	}
+	if isFileType(c.Field.Type) {
+		return len(c.files())
+	}
+	f := c.formOrDefault()
+	fm := c.meta
+	if fm == nil {
+		fm = f.newFieldMeta(c.Field)
+	}
+	return len(f.sliceInputs(fm, c.Key, c.Source))
+}
+
 //Min min
 func Min(ctx Context) error {
 	return MinOrMax(ctx, "min")
@@ -147,12 +398,18 @@ func Range(ctx Context) error {
 	if len(ctx.Params) != 2 {
 		return fmt.Errorf("参数错误")
 	}
-	params := ctx.Params
-	ctx.Params = []string{params[0]}
+	params, limits := ctx.Params, ctx.limits
+	ctx.Params = params[:1:1]
+	if len(limits) == 2 {
+		ctx.limits = limits[:1:1]
+	}
 	if err := Min(ctx); err != nil {
 		return err
 	}
-	ctx.Params = []string{params[1]}
+	ctx.Params = params[1:]
+	if len(limits) == 2 {
+		ctx.limits = limits[1:]
+	}
 	return Max(ctx)
 }
 
@@ -162,7 +419,7 @@ func Alpha(ctx Context) error {
 		return nil
 	}
 	if !IsAlpha(ctx.Input) {
-		return fmt.Errorf("%s只允许包含字母", ctx.Title)
+		return ctx.Fail("alpha")
 	}
 	return nil
 }
@@ -173,7 +430,7 @@ func Numeric(ctx Context) error {
 		return nil
 	}
 	if !IsNumeric(ctx.Input) {
-		return fmt.Errorf("%s只允许包含数字", ctx.Title)
+		return ctx.Fail("numeric")
 	}
 	return nil
 }
@@ -184,7 +441,7 @@ func AlphaNumeric(ctx Context) error {
 		return nil
 	}
 	if !IsAlphaNumeric(ctx.Input) {
-		return fmt.Errorf("%s只允许包含数字或字母", ctx.Title)
+		return ctx.Fail("alphanumeric")
 	}
 	return nil
 }
@@ -195,7 +452,7 @@ func AlphaDash(ctx Context) error {
 		return nil
 	}
 	if !IsAlphaDash(ctx.Input) {
-		return fmt.Errorf("%s只允许包含数字或字母以及下划线", ctx.Title)
+		return ctx.Fail("alphadash")
 	}
 	return nil
 }
@@ -206,14 +463,14 @@ func UserName(ctx Context) error {
 		return nil
 	}
 	if !IsAlphaDash(ctx.Input) {
-		return fmt.Errorf("%s只允许包含数字或字母以及下划线", ctx.Title)
+		return ctx.Fail("alphadash")
 	}
 	s := fmt.Sprintf("%c", ctx.Input[0])
 	if !IsAlpha(s) {
-		return fmt.Errorf("%s的第一个字符必须为字母", ctx.Title)
+		return ctx.Fail("username_first")
 	}
 	if ctx.Input[len(ctx.Input)-1] == '_' {
-		return fmt.Errorf("%s的最后一个字符不能为_", ctx.Title)
+		return ctx.Fail("username_last")
 	}
 	return nil
 }
@@ -224,7 +481,7 @@ func Float(ctx Context) error {
 		return nil
 	}
 	if !IsFloat(ctx.Input) {
-		return fmt.Errorf("%s必须为浮点数", ctx.Title)
+		return ctx.Fail("float")
 	}
 	return nil
 }
@@ -235,7 +492,7 @@ func Integer(ctx Context) error {
 		return nil
 	}
 	if !IsInteger(ctx.Input) {
-		return fmt.Errorf("%s必须为整数", ctx.Title)
+		return ctx.Fail("integer")
 	}
 	return nil
 }
@@ -246,7 +503,7 @@ func Email(ctx Context) error {
 		return nil
 	}
 	if !IsEmail(ctx.Input) {
-		return fmt.Errorf("%s不是正确email格式", ctx.Title)
+		return ctx.Fail("email")
 	}
 	return nil
 }
@@ -257,7 +514,7 @@ func IPv4(ctx Context) error {
 		return nil
 	}
 	if !IsIPv4(ctx.Input) {
-		return fmt.Errorf("%s必须为正确的IPv4格式", ctx.Title)
+		return ctx.Fail("ipv4")
 	}
 	return nil
 }
@@ -268,7 +525,7 @@ func Mobile(ctx Context) error {
 		return nil
 	}
 	if !IsMobile(ctx.Input) {
-		return fmt.Errorf("%s必须为正确的手机号码", ctx.Title)
+		return ctx.Fail("mobile")
 	}
 	return nil
 }
@@ -279,7 +536,7 @@ func Mobile2(ctx Context) error {
 		return nil
 	}
 	if !IsMobile2(ctx.Input) {
-		return fmt.Errorf("%s必须为正确的手机号码", ctx.Title)
+		return ctx.Fail("mobile")
 	}
 	return nil
 }
@@ -290,7 +547,7 @@ func Tel(ctx Context) error {
 		return nil
 	}
 	if !IsTel(ctx.Input) {
-		return fmt.Errorf("%s必须为正确的座机号码", ctx.Title)
+		return ctx.Fail("tel")
 	}
 	return nil
 }
@@ -301,7 +558,7 @@ func Phone(ctx Context) error {
 		return nil
 	}
 	if !IsPhone(ctx.Input) {
-		return fmt.Errorf("%s必须为正确的手机或座机号码", ctx.Title)
+		return ctx.Fail("phone")
 	}
 	return nil
 }
@@ -312,7 +569,271 @@ func IDCard(ctx Context) error {
 		return nil
 	}
 	if !IsIDCard(ctx.Input) {
-		return fmt.Errorf("%s必须为正确的身份证号码", ctx.Title)
+		return ctx.Fail("idcard")
 	}
 	return nil
 }
+
+//lookupField 查找同一struct中名为name的字段，返回其标题以及转换为字段类型后的值。
+//CheckInput模式下值由该字段的表单输入转换而来，没有输入时为零值
+func (c Context) lookupField(name string) (string, reflect.Value, error) {
+	if !c.Parent.IsValid() {
+		return "", reflect.Value{}, fmt.Errorf("参数错误:找不到字段%s", name)
+	}
+	f := c.formOrDefault()
+	fm, ok := f.fieldMeta(c.Parent.Type(), name)
+	if !ok {
+		return "", reflect.Value{}, fmt.Errorf("参数错误:找不到字段%s", name)
+	}
+	sf := fm.field
+	title := fm.title
+	if f.CheckMode == CheckValue {
+		return title, fieldByIndex(c.Parent, sf), nil
+	}
+	v := reflect.New(sf.Type).Elem()
+	if c.Source == nil {
+		return title, v, nil
+	}
+	input := f.formValue(fm, f.joinKey(c.prefix, fm.name), c.Source)
+	if input == "" {
+		return title, v, nil
+	}
+	if err := f.setValue(sf, v, input); err != nil {
+		return "", reflect.Value{}, err
+	}
+	return title, v, nil
+}
+
+//otherInput 返回同一struct中名为name的字段的输入，struct中没有该字段时将name作为表单的key读取
+func (c Context) otherInput(name string) string {
+	f := c.formOrDefault()
+	var meta *fieldMeta
+	if c.Parent.IsValid() {
+		if fm, ok := f.fieldMeta(c.Parent.Type(), name); ok {
+			if f.CheckMode == CheckValue {
+				v := fieldByIndex(c.Parent, fm.field)
+				s := f.valueString(v)
+				if s == "" && isNumberKind(v.Kind()) {
+					//与checkField一致，required_if:X,0需要看到数值0
+					s = f.formatValue(v)
+				}
+				return s
+			}
+			meta = fm
+			name = f.joinKey(c.prefix, fm.name)
+		}
+	}
+	return f.formValue(meta, name, c.Source)
+}
+
+//typedValue 返回当前字段转换为字段类型后的值
+func (c Context) typedValue() (reflect.Value, error) {
+	f := c.formOrDefault()
+	if f.CheckMode == CheckValue {
+		return c.Value, nil
+	}
+	v := reflect.New(c.Field.Type).Elem()
+	if err := f.setValue(c.Field, v, c.Input); err != nil {
+		if re, ok := err.(ruleError); ok {
+			return v, c.Fail(re.rule)
+		}
+		return v, err
+	}
+	return v, nil
+}
+
+func (c Context) formOrDefault() *Form {
+	if c.form == nil {
+		return form
+	}
+	return c.form
+}
+
+//fieldComparisons 字段比较规则的错误信息key以及判断比较结果是否符合要求的函数
+var fieldComparisons = map[string]struct {
+	key string
+	ok  func(n int) bool
+}{
+	"eqfield":  {"eqfield", func(n int) bool { return n == 0 }},
+	"nefield":  {"nefield", func(n int) bool { return n != 0 }},
+	"gtfield":  {"gtfield", func(n int) bool { return n > 0 }},
+	"gtefield": {"min", func(n int) bool { return n >= 0 }},
+	"ltfield":  {"ltfield", func(n int) bool { return n < 0 }},
+	"ltefield": {"max", func(n int) bool { return n <= 0 }},
+}
+
+//compareField 比较当前字段与同级字段，rule为fieldComparisons中的规则
+func compareField(ctx Context, rule string) error {
+	if ctx.Input == "" {
+		return nil
+	}
+	if len(ctx.Params) != 1 {
+		return fmt.Errorf("参数错误")
+	}
+	title, other, err := ctx.lookupField(ctx.Params[0])
+	if err != nil {
+		if _, isRule := err.(ruleError); isRule {
+			//另一个字段的输入有误，由它自己的规则报错
+			return nil
+		}
+		return err
+	}
+	v, err := ctx.typedValue()
+	if err != nil {
+		return err
+	}
+	n, err := compareValues(v, other)
+	if err != nil {
+		return err
+	}
+	if cmp := fieldComparisons[rule]; !cmp.ok(n) {
+		return ctx.Fail(cmp.key, title)
+	}
+	return nil
+}
+
+//EqField 必须与指定字段的值相等
+func EqField(ctx Context) error {
+	return compareField(ctx, "eqfield")
+}
+
+//NeField 不能与指定字段的值相等
+func NeField(ctx Context) error {
+	return compareField(ctx, "nefield")
+}
+
+//GtField 必须大于指定字段的值
+func GtField(ctx Context) error {
+	return compareField(ctx, "gtfield")
+}
+
+//GteField 不能小于指定字段的值
+func GteField(ctx Context) error {
+	return compareField(ctx, "gtefield")
+}
+
+//LtField 必须小于指定字段的值
+func LtField(ctx Context) error {
+	return compareField(ctx, "ltfield")
+}
+
+//LteField 不能大于指定字段的值
+func LteField(ctx Context) error {
+	return compareField(ctx, "ltefield")
+}
+
+//files 返回上传文件字段的文件，CheckInput模式下从请求中读取
+func (c Context) files() []*multipart.FileHeader {
+	if c.formOrDefault().CheckMode != CheckValue {
+		return requestFiles(c.Source, c.Key)
+	}
+	if !c.Value.CanInterface() {
+		return nil
+	}
+	switch fh := c.Value.Interface().(type) {
+	case *multipart.FileHeader:
+		if fh != nil {
+			return []*multipart.FileHeader{fh}
+		}
+	case []*multipart.FileHeader:
+		return fh
+	}
+	return nil
+}
+
+//FileSize 上传文件的大小不能超过指定值，如filesize:2MB
+func FileSize(ctx Context) error {
+	if len(ctx.Params) != 1 {
+		return fmt.Errorf("参数错误")
+	}
+	if !isFileType(ctx.Field.Type) {
+		return fmt.Errorf("未支持格式%v", ctx.Field.Type)
+	}
+	max, err := parseSize(ctx.Params[0])
+	if err != nil {
+		return fmt.Errorf("参数错误:%v", err)
+	}
+	for _, fh := range ctx.files() {
+		if fh.Size > max {
+			return ctx.Fail("filesize", ctx.Params[0])
+		}
+	}
+	return nil
+}
+
+//MimeType 上传文件的类型必须为指定类型之一，如mimetype:image/png,image/jpeg，也可以使用image/*。
+//文件类型由文件内容判断，不使用客户端提供的Content-Type
+func MimeType(ctx Context) error {
+	if len(ctx.Params) == 0 {
+		return fmt.Errorf("参数错误")
+	}
+	if !isFileType(ctx.Field.Type) {
+		return fmt.Errorf("未支持格式%v", ctx.Field.Type)
+	}
+	for _, fh := range ctx.files() {
+		mimeType, err := sniffFile(fh)
+		if err != nil {
+			return err
+		}
+		if !matchMimeType(mimeType, ctx.Params) {
+			return ctx.Fail("mimetype", strings.Join(ctx.Params, ","))
+		}
+	}
+	return nil
+}
+
+//Ext 上传文件的扩展名必须为指定值之一，如ext:pdf,docx，不区分大小写
+func Ext(ctx Context) error {
+	if len(ctx.Params) == 0 {
+		return fmt.Errorf("参数错误")
+	}
+	if !isFileType(ctx.Field.Type) {
+		return fmt.Errorf("未支持格式%v", ctx.Field.Type)
+	}
+	for _, fh := range ctx.files() {
+		ext := strings.TrimPrefix(filepath.Ext(fh.Filename), ".")
+		ok := false
+		for _, p := range ctx.Params {
+			if strings.EqualFold(ext, strings.TrimPrefix(p, ".")) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return ctx.Fail("ext", strings.Join(ctx.Params, ","))
+		}
+	}
+	return nil
+}
+
+//sniffFile 根据文件开头的内容判断文件类型
+func sniffFile(fh *multipart.FileHeader) (string, error) {
+	file, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	mimeType := http.DetectContentType(buf[:n])
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return mimeType, nil
+}
+
+func matchMimeType(mimeType string, patterns []string) bool {
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if strings.EqualFold(p, mimeType) {
+			return true
+		}
+		if strings.HasSuffix(p, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,881 @@
+//echo-formgen 为标记了//echo-form:generate的struct生成不使用反射的绑定和校验函数。
+//
+//用法：在包中加入
+//
+//	//go:generate echo-formgen
+//
+//生成的文件(默认为form_gen.go)中每个标记了的struct Xxx有BindXxx和CheckXxx两个函数(类型不可导出时为bindXxx和checkXxx)，
+//并在init中注册到form包，之后Form.Bind、Form.Check等遇到该类型时自动使用生成的代码。
+//标记了的struct引用的嵌套struct只生成不可导出的辅助函数，不注册。
+//
+//校验规则在生成时解析，min、max、range的参数按字段类型解析好，引用的同级字段也在生成时找到，
+//内置检测器直接调用，只有自定义的检测器在校验时使用反射。规则的参数有误时生成失败。
+//
+//支持的字段类型为string、bool、整数、浮点数、time.Time、time.Duration，这些类型的指针和slice，
+//以及同一个包中的struct(包括匿名嵌入的struct)。其他类型需要去掉标记使用反射绑定
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	form "github.com/jiazhoulvke/echo-form"
+)
+
+const directive = "//echo-form:generate"
+
+const formPath = "github.com/jiazhoulvke/echo-form"
+
+func main() {
+	dir := flag.String("dir", ".", "包所在的目录")
+	output := flag.String("o", "form_gen.go", "生成的文件名，相对于dir")
+	flag.Parse()
+	src, err := generate(*dir, *output)
+	if err != nil {
+		log.Fatalf("echo-formgen: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(*dir, *output), src, 0644); err != nil {
+		log.Fatalf("echo-formgen: %v", err)
+	}
+}
+
+//kind 字段的类型
+type kind int
+
+const (
+	kindString kind = iota
+	kindBool
+	kindInt
+	kindUint
+	kindFloat
+	kindTime
+	kindDuration
+	kindStruct
+)
+
+//basicTypes 内置类型的kind和位数，位数为0时与int一致
+var basicTypes = map[string]struct {
+	kind kind
+	bits int
+}{
+	"string":  {kindString, 0},
+	"bool":    {kindBool, 0},
+	"int":     {kindInt, 0},
+	"int8":    {kindInt, 8},
+	"int16":   {kindInt, 16},
+	"int32":   {kindInt, 32},
+	"rune":    {kindInt, 32},
+	"int64":   {kindInt, 64},
+	"uint":    {kindUint, 0},
+	"uint8":   {kindUint, 8},
+	"byte":    {kindUint, 8},
+	"uint16":  {kindUint, 16},
+	"uint32":  {kindUint, 32},
+	"uint64":  {kindUint, 64},
+	"float32": {kindFloat, 32},
+	"float64": {kindFloat, 64},
+}
+
+type field struct {
+	name      string
+	anonymous bool
+	exported  bool
+	tag       form.TagInfo
+	structTag reflect.StructTag
+	kind      kind
+	bits      int
+	//typ 元素的类型，如int8、time.Time
+	typ   string
+	ptr   bool
+	slice bool
+}
+
+type structType struct {
+	name   string
+	fields []field
+	//skipped key为-的字段
+	skipped map[string]bool
+	//desc 按form.GenHash的规则对struct的描述
+	desc string
+}
+
+type generator struct {
+	form    *form.Form
+	pkg     string
+	structs map[string]*ast.StructType
+	//timeNames 每个struct所在文件中time包的名字
+	timeNames map[string]string
+	done      map[string]*structType
+	order     []string
+	//roots 标记了directive的struct，只有它们生成可导出的函数并注册
+	roots map[string]bool
+	//limits 生成的min、max、range规则
+	limits []string
+}
+
+//generate 读取dir中的包并返回生成的代码
+func generate(dir, output string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != output
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%s中有%d个包", dir, len(pkgs))
+	}
+	g := &generator{
+		form:      form.New(),
+		structs:   make(map[string]*ast.StructType),
+		timeNames: make(map[string]string),
+		done:      make(map[string]*structType),
+		roots:     make(map[string]bool),
+	}
+	var roots []string
+	for name, pkg := range pkgs {
+		g.pkg = name
+		files := make([]string, 0, len(pkg.Files))
+		for filename := range pkg.Files {
+			files = append(files, filename)
+		}
+		sort.Strings(files)
+		for _, filename := range files {
+			roots = append(roots, g.collect(pkg.Files[filename])...)
+		}
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("%s中没有标记了%s的struct", dir, directive)
+	}
+	for _, name := range roots {
+		g.roots[name] = true
+		if _, err := g.resolve(name); err != nil {
+			return nil, err
+		}
+	}
+	return g.render()
+}
+
+//collect 记录文件中的struct类型，返回标记了directive的struct
+func (g *generator) collect(file *ast.File) []string {
+	timeName := ""
+	for _, imp := range file.Imports {
+		if imp.Path.Value == `"time"` {
+			timeName = "time"
+			if imp.Name != nil {
+				timeName = imp.Name.Name
+			}
+		}
+	}
+	var roots []string
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			g.structs[ts.Name.Name] = st
+			g.timeNames[ts.Name.Name] = timeName
+			doc := ts.Doc
+			if doc == nil && len(gd.Specs) == 1 {
+				doc = gd.Doc
+			}
+			if hasDirective(doc) {
+				roots = append(roots, ts.Name.Name)
+			}
+		}
+	}
+	return roots
+}
+
+func hasDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == directive {
+			return true
+		}
+	}
+	return false
+}
+
+//resolve 解析struct name的字段，字段中引用的struct一并解析
+func (g *generator) resolve(name string) (*structType, error) {
+	if st, ok := g.done[name]; ok {
+		if st == nil {
+			return nil, fmt.Errorf("%s不能直接或间接包含自身", name)
+		}
+		return st, nil
+	}
+	node, ok := g.structs[name]
+	if !ok {
+		return nil, fmt.Errorf("找不到struct %s", name)
+	}
+	g.done[name] = nil
+	st := &structType{name: name, skipped: make(map[string]bool)}
+	var desc strings.Builder
+	desc.WriteString("{")
+	for _, af := range node.Fields.List {
+		tag := ""
+		if af.Tag != nil {
+			tag, _ = strconv.Unquote(af.Tag.Value)
+		}
+		names := make([]string, 0, len(af.Names))
+		for _, n := range af.Names {
+			names = append(names, n.Name)
+		}
+		anonymous := len(names) == 0
+		if anonymous {
+			names = append(names, embeddedName(af.Type))
+		}
+		for _, n := range names {
+			f := field{
+				name:      n,
+				anonymous: anonymous,
+				exported:  token.IsExported(n),
+				structTag: reflect.StructTag(tag),
+			}
+			f.tag = g.form.TagInfo(reflect.StructField{
+				Name:      n,
+				Tag:       f.structTag,
+				Anonymous: anonymous,
+			})
+			if f.tag.Skip {
+				st.skipped[n] = true
+				fmt.Fprintf(&desc, "%s - %s;", n, strconv.Quote(tag))
+				continue
+			}
+			if err := g.fieldType(&f, af.Type, g.timeNames[name]); err != nil {
+				return nil, fmt.Errorf("%s.%s: %v", name, n, err)
+			}
+			//与反射时一致，逐个字段绑定的struct使用其描述，其它字段使用reflect.Type.String()
+			var typ string
+			if f.kind == kindStruct {
+				typ = g.done[f.typ].desc
+			} else {
+				typ = f.structField().Type.String()
+			}
+			fmt.Fprintf(&desc, "%s %s %s;", n, typ, strconv.Quote(tag))
+			st.fields = append(st.fields, f)
+		}
+	}
+	desc.WriteString("}")
+	st.desc = desc.String()
+	g.done[name] = st
+	g.order = append(g.order, name)
+	return st, nil
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
+
+//fieldType 根据字段类型的表达式设置f的kind、typ等
+func (g *generator) fieldType(f *field, expr ast.Expr, timeName string) error {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		if f.ptr || f.slice {
+			return fmt.Errorf("不支持的类型%s", exprString(expr))
+		}
+		f.ptr = true
+		if err := g.fieldType(f, t.X, timeName); err != nil {
+			return err
+		}
+		if f.kind == kindStruct {
+			return fmt.Errorf("不支持指向struct的指针")
+		}
+		return nil
+	case *ast.ArrayType:
+		if t.Len != nil || f.ptr || f.slice {
+			return fmt.Errorf("不支持的类型%s", exprString(expr))
+		}
+		f.slice = true
+		if err := g.fieldType(f, t.Elt, timeName); err != nil {
+			return err
+		}
+		if f.kind == kindStruct {
+			return fmt.Errorf("不支持元素为struct的slice")
+		}
+		return nil
+	case *ast.Ident:
+		if b, ok := basicTypes[t.Name]; ok {
+			f.kind, f.bits, f.typ = b.kind, b.bits, t.Name
+			return nil
+		}
+		if _, ok := g.structs[t.Name]; ok {
+			if _, err := g.resolve(t.Name); err != nil {
+				return err
+			}
+			f.kind, f.typ = kindStruct, t.Name
+			return nil
+		}
+	case *ast.SelectorExpr:
+		if x, ok := t.X.(*ast.Ident); ok && timeName != "" && x.Name == timeName {
+			switch t.Sel.Name {
+			case "Time":
+				f.kind, f.typ = kindTime, "time.Time"
+				return nil
+			case "Duration":
+				f.kind, f.typ = kindDuration, "time.Duration"
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("不支持的类型%s", exprString(expr))
+}
+
+func exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	format.Node(&buf, token.NewFileSet(), expr)
+	return buf.String()
+}
+
+//usesTime 生成的代码是否需要导入time包，只有time类型的slice、指针以及time.Time字段的比较会用到类型名
+func (g *generator) usesTime() bool {
+	for _, name := range g.order {
+		for _, fd := range g.done[name].fields {
+			if (fd.slice || fd.ptr) && (fd.kind == kindTime || fd.kind == kindDuration) {
+				return true
+			}
+			if fd.kind != kindTime {
+				continue
+			}
+			for _, r := range fd.tag.Rules {
+				if compareRules[r.Name] {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+//reflectTypes 字段元素类型对应的reflect.Type，用于按form包的规则解析tag
+var reflectTypes = map[string]reflect.Type{
+	"string":        reflect.TypeOf(""),
+	"bool":          reflect.TypeOf(false),
+	"int":           reflect.TypeOf(int(0)),
+	"int8":          reflect.TypeOf(int8(0)),
+	"int16":         reflect.TypeOf(int16(0)),
+	"int32":         reflect.TypeOf(int32(0)),
+	"rune":          reflect.TypeOf(rune(0)),
+	"int64":         reflect.TypeOf(int64(0)),
+	"uint":          reflect.TypeOf(uint(0)),
+	"uint8":         reflect.TypeOf(uint8(0)),
+	"byte":          reflect.TypeOf(byte(0)),
+	"uint16":        reflect.TypeOf(uint16(0)),
+	"uint32":        reflect.TypeOf(uint32(0)),
+	"uint64":        reflect.TypeOf(uint64(0)),
+	"float32":       reflect.TypeOf(float32(0)),
+	"float64":       reflect.TypeOf(float64(0)),
+	"time.Time":     reflect.TypeOf(time.Time{}),
+	"time.Duration": reflect.TypeOf(time.Duration(0)),
+}
+
+//structField 返回与字段对应的reflect.StructField，只用于解析tag，嵌套的struct没有对应的类型
+func (fd field) structField() reflect.StructField {
+	t := reflectTypes[fd.typ]
+	if fd.ptr {
+		t = reflect.PtrTo(t)
+	}
+	if fd.slice {
+		t = reflect.SliceOf(t)
+	}
+	return reflect.StructField{Name: fd.name, Type: t, Tag: fd.structTag, Anonymous: fd.anonymous}
+}
+
+func (g *generator) render() ([]byte, error) {
+	var buf bytes.Buffer
+	w := func(format string, args ...interface{}) {
+		fmt.Fprintf(&buf, format, args...)
+	}
+	w("// Code generated by echo-formgen. DO NOT EDIT.\n\n")
+	w("package %s\n\n", g.pkg)
+	w("import (\n")
+	if g.usesTime() {
+		w("\t\"time\"\n\n")
+	}
+	w("\tform %q\n", formPath)
+	w(")\n\n")
+	w("func init() {\n")
+	for _, name := range g.order {
+		if !g.roots[name] {
+			continue
+		}
+		w("\tform.RegisterGenerated((*%s)(nil), form.Generated{\n", name)
+		w("\t\tBind: func(f *form.Form, o interface{}, src form.ValueSource) error {\n")
+		w("\t\t\treturn formgenBind%s(f, o.(*%s), src, \"\")\n", name, name)
+		w("\t\t},\n")
+		w("\t\tCheck: func(f *form.Form, o interface{}, src form.ValueSource, errs *form.ValidationErrors) error {\n")
+		w("\t\t\treturn formgenCheck%s(f, o.(*%s), src, \"\", errs)\n", name, name)
+		w("\t\t},\n")
+		w("\t\tHash: %q,\n", form.GenHash(g.done[name].desc))
+		w("\t})\n")
+	}
+	w("}\n")
+	for _, name := range g.order {
+		if err := g.renderStruct(w, g.done[name]); err != nil {
+			return nil, err
+		}
+	}
+	if len(g.limits) > 0 {
+		w("\n// formgenLimits 生成时按字段类型解析好的min、max、range规则\n")
+		w("var formgenLimits = [...]form.GenLimit{\n")
+		for _, l := range g.limits {
+			w("%s", l)
+		}
+		w("}\n")
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("格式化生成的代码失败: %v\n%s", err, buf.String())
+	}
+	return src, nil
+}
+
+func (g *generator) renderStruct(w func(string, ...interface{}), st *structType) error {
+	name := st.name
+	if g.roots[name] {
+		//不可导出的类型生成不可导出的函数，避免可导出的函数使用不可导出的参数类型
+		bind, check := "Bind", "Check"
+		if !token.IsExported(name) {
+			bind, check = "bind", "check"
+		}
+		w("\n// %s%s 绑定%s，与form.Form.Bind相同，f为nil时使用form.Default()\n", bind, exportName(name), name)
+		w("func %s%s(f *form.Form, o *%s, src form.ValueSource) error {\n", bind, exportName(name), name)
+		w("\tif f == nil {\n\t\tf = form.Default()\n\t}\n")
+		w("\treturn formgenBind%s(f, o, src, \"\")\n}\n", name)
+		w("\n// %s%s 校验%s，与form.Form.Check相同，f为nil时使用form.Default()\n", check, exportName(name), name)
+		w("func %s%s(f *form.Form, o *%s, src form.ValueSource) error {\n", check, exportName(name), name)
+		w("\tif f == nil {\n\t\tf = form.Default()\n\t}\n")
+		w("\treturn f.CheckSource(o, src)\n}\n")
+	}
+
+	w("\nfunc formgenBind%s(f *form.Form, o *%s, src form.ValueSource, prefix string) error {\n", name, name)
+	for _, fd := range st.fields {
+		if fd.anonymous && fd.kind == kindStruct {
+			//与反射一致，不可导出的匿名struct中可导出的字段仍然绑定
+			w("\tif err := formgenBind%s(f, &o.%s, src, prefix); err != nil {\n\t\treturn err\n\t}\n", fd.typ, fd.name)
+			continue
+		}
+		if !fd.exported {
+			//反射绑定时不可导出的字段不能赋值
+			continue
+		}
+		if fd.kind == kindStruct {
+			w("\tif err := formgenBind%s(f, &o.%s, src, %s); err != nil {\n\t\treturn err\n\t}\n", fd.typ, fd.name, nestedPrefix(fd))
+			continue
+		}
+		renderBindField(w, fd)
+	}
+	w("\treturn nil\n}\n")
+
+	if !g.roots[name] && !g.nestedCheck(name) {
+		//只被匿名嵌入的struct的校验代码展开在外层的函数中
+		return nil
+	}
+	w("\nfunc formgenCheck%s(f *form.Form, o *%s, src form.ValueSource, prefix string, errs *form.ValidationErrors) error {\n", name, name)
+	if err := g.renderCheckFields(w, st, st, "o"); err != nil {
+		return err
+	}
+	w("\treturn nil\n}\n")
+	return nil
+}
+
+//nestedCheck struct name是否作为非匿名的嵌套字段出现，即是否需要单独的校验函数
+func (g *generator) nestedCheck(name string) bool {
+	for _, other := range g.order {
+		for _, fd := range g.done[other].fields {
+			if fd.kind == kindStruct && !fd.anonymous && fd.typ == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//renderCheckFields 生成校验st中字段的代码。parent为规则引用的同级字段所在的struct，
+//匿名嵌入的struct展开到外层的函数中，与外层共用前缀和同级字段。owner为st的值的表达式
+func (g *generator) renderCheckFields(w func(string, ...interface{}), parent, st *structType, owner string) error {
+	for _, fd := range st.fields {
+		if fd.kind == kindStruct {
+			if fd.anonymous {
+				if err := g.renderCheckFields(w, parent, g.done[fd.typ], owner+"."+fd.name); err != nil {
+					return err
+				}
+				continue
+			}
+			w("\tif err := formgenCheck%s(f, &%s.%s, src, %s, errs); err != nil {\n\t\treturn err\n\t}\n", fd.typ, owner, fd.name, nestedPrefix(fd))
+			continue
+		}
+		if len(fd.tag.Rules) == 0 {
+			continue
+		}
+		w("\t{\n")
+		w("\t\tkey := f.GenKey(prefix, %q)\n", fd.tag.Key)
+		if fd.slice {
+			w("\t\tc := f.GenSliceField(src, errs, prefix, %q, key, %q, f.GenInputs(src, key, %q, %t, %q), %q)\n",
+				fd.name, fd.tag.Title, fd.tag.From, fd.tag.Quoted, fd.tag.Sep, fd.tag.Message)
+		} else {
+			w("\t\tc := f.GenField(src, errs, prefix, %q, key, %q, f.GenInput(src, key, %q, %t), %q)\n",
+				fd.name, fd.tag.Title, fd.tag.From, fd.tag.Quoted, fd.tag.Message)
+		}
+		for _, r := range fd.tag.Rules {
+			if err := g.renderRule(w, parent, st, fd, owner, r); err != nil {
+				return fmt.Errorf("%s.%s: %v", st.name, fd.name, err)
+			}
+		}
+		w("\t}\n")
+	}
+	return nil
+}
+
+//ruleFuncs 只读取输入的内置检测器，生成的代码直接调用
+var ruleFuncs = map[string]string{
+	"alpha":        "Alpha",
+	"numeric":      "Numeric",
+	"alphanumeric": "AlphaNumeric",
+	"alphadash":    "AlphaDash",
+	"username":     "UserName",
+	"float":        "Float",
+	"integer":      "Integer",
+	"email":        "Email",
+	"ipv4":         "IPv4",
+	"mobile":       "Mobile",
+	"mobile2":      "Mobile2",
+	"tel":          "Tel",
+	"phone":        "Phone",
+	"idcard":       "IDCard",
+}
+
+//compareRules 比较同级字段的内置规则
+var compareRules = map[string]bool{
+	"eqfield":  true,
+	"nefield":  true,
+	"gtfield":  true,
+	"gtefield": true,
+	"ltfield":  true,
+	"ltefield": true,
+}
+
+//builtinRules 其它内置规则，不在这些map中的规则视为自定义检测器
+var builtinRules = map[string]bool{
+	"required":         true,
+	"min":              true,
+	"max":              true,
+	"range":            true,
+	"required_if":      true,
+	"required_unless":  true,
+	"required_with":    true,
+	"required_without": true,
+	"filesize":         true,
+	"mimetype":         true,
+	"ext":              true,
+}
+
+//renderRule 生成字段fd的规则r的校验代码，内置规则的参数在这里检查
+func (g *generator) renderRule(w func(string, ...interface{}), parent, st *structType, fd field, owner string, r form.TagRule) error {
+	params := strings.Join(r.Params, ",")
+	check := func(call string) {
+		w("\t\tif err := %s; err != nil {\n\t\t\treturn err\n\t\t}\n", call)
+	}
+	builtin := ruleFuncs[r.Name] != "" || compareRules[r.Name] || builtinRules[r.Name]
+	if builtin {
+		if err := g.form.ValidateRule(fd.structField(), r, nil); err != nil {
+			return err
+		}
+	}
+	switch {
+	case ruleFuncs[r.Name] != "":
+		check(fmt.Sprintf("c.Rule(%q, %q, form.%s)", r.Name, params, ruleFuncs[r.Name]))
+	case r.Name == "required":
+		check(fmt.Sprintf("c.Required(%q, %q)", r.Name, params))
+	case r.Name == "min", r.Name == "max", r.Name == "range":
+		l, err := g.form.GenLimit(fd.structField(), r)
+		if err != nil {
+			return err
+		}
+		g.limits = append(g.limits, renderLimit(l, fmt.Sprintf("%s.%s %s:%s", st.name, fd.name, r.Name, params)))
+		check(fmt.Sprintf("c.Limit(&formgenLimits[%d])", len(g.limits)-1))
+	case compareRules[r.Name]:
+		other, ok, err := g.lookupField(parent, r.Params[0])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%s:找不到字段%s", r.Name, r.Params[0])
+		}
+		class, otherClass := compareClass(fd), compareClass(other)
+		if class == "" || class != otherClass {
+			return fmt.Errorf("%s:无法比较%s和%s", r.Name, fd.name, other.name)
+		}
+		call := fmt.Sprintf("c.Compare%s(%q, %q, %q, %s", class, r.Name, params, other.tag.Title, otherInput(other))
+		if x := parseFunc(fd); x != "" {
+			//转换函数较长，每个参数一行
+			call += ",\n" + x + ",\n" + parseFunc(other) + ",\n"
+		}
+		check(call + ")")
+	case r.Name == "required_if", r.Name == "required_unless":
+		input, err := g.otherInput(parent, r.Params[0])
+		if err != nil {
+			return err
+		}
+		op, join := "==", " || "
+		if r.Name == "required_unless" {
+			op, join = "!=", " && "
+		}
+		conds := make([]string, 0, len(r.Params)-1)
+		for _, v := range r.Params[1:] {
+			conds = append(conds, fmt.Sprintf("other %s %q", op, v))
+		}
+		w("\t\tif other := %s; %s {\n", input, strings.Join(conds, join))
+		w("\t\t\tif err := c.Required(%q, %q); err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\t\t}\n", r.Name, params)
+	case r.Name == "required_with", r.Name == "required_without":
+		op := "!="
+		if r.Name == "required_without" {
+			op = "=="
+		}
+		conds := make([]string, 0, len(r.Params))
+		for _, name := range r.Params {
+			input, err := g.otherInput(parent, name)
+			if err != nil {
+				return err
+			}
+			conds = append(conds, fmt.Sprintf("%s %s \"\"", input, op))
+		}
+		w("\t\tif %s {\n", strings.Join(conds, " || "))
+		w("\t\t\tif err := c.Required(%q, %q); err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\t\t}\n", r.Name, params)
+	case builtin:
+		//filesize等规则只能用于上传文件，ValidateRule已经报错
+		return fmt.Errorf("%s:不支持", r.Name)
+	default:
+		ptr := owner
+		if owner != "o" {
+			ptr = "&" + owner
+		}
+		check(fmt.Sprintf("c.Custom(%q, %q, o, %s, %q)", r.Name, params, ptr, fd.name))
+	}
+	return nil
+}
+
+//lookupField 按reflect.Type.FieldByName的规则在st中查找字段，包括匿名嵌入的struct中的字段。
+//引用了key为-的字段时返回错误，反射校验时这样的字段的key与生成的代码不一致
+func (g *generator) lookupField(st *structType, name string) (field, bool, error) {
+	level := []*structType{st}
+	for len(level) > 0 {
+		var found []field
+		var next []*structType
+		for _, s := range level {
+			if s.skipped[name] {
+				return field{}, false, fmt.Errorf("不能引用key为-的字段%s", name)
+			}
+			for _, fd := range s.fields {
+				if fd.name == name {
+					found = append(found, fd)
+				}
+				if fd.anonymous && fd.kind == kindStruct {
+					next = append(next, g.done[fd.typ])
+				}
+			}
+		}
+		if len(found) == 1 {
+			return found[0], true, nil
+		}
+		if len(found) > 1 {
+			//同一层有多个同名字段时FieldByName找不到
+			return field{}, false, nil
+		}
+		level = next
+	}
+	return field{}, false, nil
+}
+
+//otherInput 返回读取同级字段name的输入的表达式，st中没有该字段时将name作为表单的key，与Context.otherInput一致
+func (g *generator) otherInput(st *structType, name string) (string, error) {
+	fd, ok, err := g.lookupField(st, name)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return fmt.Sprintf("f.GenInput(src, %q, \"\", false)", name), nil
+	}
+	return otherInput(fd), nil
+}
+
+//otherInput 返回读取字段fd的输入的表达式，fd与当前字段共用前缀
+func otherInput(fd field) string {
+	return fmt.Sprintf("f.GenInput(src, f.GenKey(prefix, %q), %q, %t)", fd.tag.Key, fd.tag.From, fd.tag.Quoted)
+}
+
+//compareClass 字段比较时的类别，与form包中的kindClass一致，不能比较的类型返回空字符串
+func compareClass(fd field) string {
+	if fd.slice {
+		return ""
+	}
+	switch fd.kind {
+	case kindInt, kindDuration:
+		return "Int"
+	case kindUint:
+		return "Uint"
+	case kindFloat:
+		return "Float"
+	case kindString:
+		return "String"
+	case kindBool:
+		return "Bool"
+	case kindTime:
+		return "Time"
+	}
+	return ""
+}
+
+//parseFunc 返回将输入转换为字段类型的函数，与反射校验时的setValue一致，字符串和bool不需要转换
+func parseFunc(fd field) string {
+	switch fd.kind {
+	case kindInt:
+		return fmt.Sprintf("func(s string) (int64, error) { return form.GenInt(s, %d, %t) }", fd.bits, fd.tag.Unit == "bytes")
+	case kindDuration:
+		return "func(s string) (int64, error) {\nd, err := form.GenDuration(s)\nreturn int64(d), err\n}"
+	case kindUint:
+		return fmt.Sprintf("func(s string) (uint64, error) { return form.GenUint(s, %d, %t) }", fd.bits, fd.tag.Unit == "bytes")
+	case kindFloat:
+		return fmt.Sprintf("func(s string) (float64, error) { return form.GenFloat(s, %d) }", fd.bits)
+	case kindTime:
+		return fmt.Sprintf("func(s string) (time.Time, error) { return f.GenTime(s, %q, %q) }", fd.tag.TimeFormat, fd.tag.TimeUnit)
+	}
+	return ""
+}
+
+//renderLimit 生成formgenLimits中的一项，comment为规则所在的字段和规则
+func renderLimit(l form.GenLimit, comment string) string {
+	bound := func(b *form.GenBound) string {
+		if l.Kind == "float" {
+			return fmt.Sprintf("&form.GenBound{Float: %s, Text: %q}", strconv.FormatFloat(b.Float, 'g', -1, 64), b.Text)
+		}
+		return fmt.Sprintf("&form.GenBound{Int: %d, Text: %q}", b.Int, b.Text)
+	}
+	items := []string{fmt.Sprintf("Rule: %q", l.Rule), fmt.Sprintf("Params: %q", l.Params), fmt.Sprintf("Kind: %q", l.Kind)}
+	if l.Unit != "" {
+		items = append(items, fmt.Sprintf("Unit: %q", l.Unit))
+	}
+	if l.Min != nil {
+		items = append(items, "Min: "+bound(l.Min))
+	}
+	if l.Max != nil {
+		items = append(items, "Max: "+bound(l.Max))
+	}
+	return fmt.Sprintf("\t// %s\n\t{%s},\n", comment, strings.Join(items, ", "))
+}
+
+func nestedPrefix(fd field) string {
+	if fd.anonymous {
+		return "prefix"
+	}
+	return fmt.Sprintf("f.GenKey(prefix, %q)", fd.tag.Key)
+}
+
+func exportName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func renderBindField(w func(string, ...interface{}), fd field) {
+	w("\t{\n")
+	w("\t\tkey := f.GenKey(prefix, %q)\n", fd.tag.Key)
+	if fd.slice {
+		w("\t\tinputs := f.GenInputs(src, key, %q, %t, %q)\n", fd.tag.From, fd.tag.Quoted, fd.tag.Sep)
+		if fd.tag.Default != "" {
+			sep := fd.tag.Sep
+			if sep == "" {
+				sep = ","
+			}
+			w("\t\tif len(inputs) == 0 {\n\t\t\tinputs = %#v\n\t\t}\n", strings.Split(fd.tag.Default, sep))
+		}
+		w("\t\tif len(inputs) > 0 {\n")
+		w("\t\t\tvalues := make([]%s, 0, len(inputs))\n", fd.typ)
+		w("\t\t\tfor _, input := range inputs {\n")
+		renderConvert(w, fd, "\t\t\t\t", "values = append(values, %s)")
+		w("\t\t\t}\n")
+		w("\t\t\to.%s = values\n", fd.name)
+		w("\t\t}\n")
+	} else {
+		w("\t\tinput := f.GenInput(src, key, %q, %t)\n", fd.tag.From, fd.tag.Quoted)
+		if fd.ptr {
+			//与反射绑定一致，key存在但值为空时指向零值，不使用默认值
+			w("\t\tif input == \"\" && f.GenHas(src, key, %q) {\n\t\t\to.%s = new(%s)\n\t\t} else {\n", fd.tag.From, fd.name, fd.typ)
+		}
+		if fd.tag.Default != "" {
+			w("\t\tif input == \"\" {\n\t\t\tinput = %q\n\t\t}\n", fd.tag.Default)
+		}
+		w("\t\tif input != \"\" {\n")
+		switch {
+		case fd.ptr:
+			renderConvert(w, fd, "\t\t\t", "value := %s\n\t\t\to."+fd.name+" = &value")
+		case fd.kind == kindBool:
+			//与反射绑定一致，有值且不为false、0时为true，否则保持原值
+			w("\t\t\tif input != \"false\" && input != \"0\" {\n\t\t\t\to.%s = true\n\t\t\t}\n", fd.name)
+		default:
+			renderConvert(w, fd, "\t\t\t", "o."+fd.name+" = %s")
+		}
+		w("\t\t}\n")
+		if fd.ptr {
+			w("\t\t}\n")
+		}
+	}
+	w("\t}\n")
+}
+
+//renderConvert 生成将input转换为字段元素类型的代码，assign为使用转换结果的语句，%s为转换后的值
+func renderConvert(w func(string, ...interface{}), fd field, indent, assign string) {
+	fail := fmt.Sprintf("return f.GenError(src, %q, key, %q, input, %q, err)", fd.name, fd.tag.Title, fd.tag.Message)
+	size := fd.tag.Unit == "bytes"
+	var call, value string
+	switch fd.kind {
+	case kindString:
+		w(indent+assign+"\n", "input")
+		return
+	case kindBool:
+		w(indent+assign+"\n", `input != "false" && input != "0"`)
+		return
+	case kindInt:
+		call, value = fmt.Sprintf("form.GenInt(input, %d, %t)", fd.bits, size), fd.typ+"(n)"
+	case kindUint:
+		call, value = fmt.Sprintf("form.GenUint(input, %d, %t)", fd.bits, size), fd.typ+"(n)"
+	case kindFloat:
+		call, value = fmt.Sprintf("form.GenFloat(input, %d)", fd.bits), fd.typ+"(n)"
+	case kindDuration:
+		call, value = "form.GenDuration(input)", "n"
+	case kindTime:
+		call, value = fmt.Sprintf("f.GenTime(input, %q, %q)", fd.tag.TimeFormat, fd.tag.TimeUnit), "n"
+	}
+	w(indent+"n, err := %s\n", call)
+	w(indent+"if err != nil {\n"+indent+"\t%s\n"+indent+"}\n", fail)
+	w(indent+assign+"\n", value)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGenerate(t *testing.T) {
+	Convey("测试生成代码", t, func() {
+		dir := filepath.Join("..", "..", "internal", "gentest")
+		code, err := generate(dir, "form_gen.go")
+		So(err, ShouldBeNil)
+		golden, err := ioutil.ReadFile(filepath.Join(dir, "form_gen.go"))
+		So(err, ShouldBeNil)
+		So(string(code), ShouldEqual, string(golden))
+
+		_, err = generate(".", "form_gen.go")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestGenerateRules(t *testing.T) {
+	Convey("测试生成时检查规则", t, func() {
+		dir, err := ioutil.TempDir("", "echo-formgen")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		gen := func(fields string) error {
+			src := "package p\n\nimport \"time\"\n\nvar _ time.Time\n\n//echo-form:generate\ntype T struct {\n" + fields + "\n}\n"
+			So(ioutil.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0644), ShouldBeNil)
+			_, err := generate(dir, "form_gen.go")
+			return err
+		}
+		So(gen("A int `valid:\"min:1;max:10;hello\"`\nB int `valid:\"gtfield:A\"`"), ShouldBeNil) //hello为自定义检测器
+		So(gen("A int `valid:\"min:abc\"`"), ShouldNotBeNil)
+		So(gen("A int `valid:\"range:1\"`"), ShouldNotBeNil)
+		So(gen("A string `valid:\"email:1\"`"), ShouldNotBeNil)
+		So(gen("A int `valid:\"eqfield:B\"`"), ShouldNotBeNil)           //找不到字段
+		So(gen("A int `valid:\"eqfield:B\"`\nB string"), ShouldNotBeNil) //类型不能比较
+		So(gen("A time.Time `valid:\"gtfield:B\"`\nB time.Time"), ShouldBeNil)
+		So(gen("A int `valid:\"eqfield:B\"`\nB int `form:\"-\"`"), ShouldNotBeNil) //key为-的字段
+		So(gen("A string `valid:\"required_if:Mode,1\"`"), ShouldBeNil)            //引用请求中的key
+		So(gen("A string `valid:\"required_if:Mode\"`"), ShouldNotBeNil)
+	})
+}
+
+func TestGenerateEntryPoints(t *testing.T) {
+	Convey("测试只为标记了的struct生成入口", t, func() {
+		dir, err := ioutil.TempDir("", "echo-formgen")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		src := "package p\n\n//echo-form:generate\ntype foo struct {\n\tbase\n\tIn inner `form:\"in\"`\n}\n\n" +
+			"type base struct {\n\tID int `form:\"id\" valid:\"required\"`\n}\n\n" +
+			"type inner struct {\n\tA int `form:\"a\" valid:\"min:1\"`\n}\n"
+		So(ioutil.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0644), ShouldBeNil)
+		out, err := generate(dir, "form_gen.go")
+		So(err, ShouldBeNil)
+		code := string(out)
+		So(code, ShouldContainSubstring, "func bindFoo(")
+		So(code, ShouldContainSubstring, "func checkFoo(")
+		So(code, ShouldContainSubstring, "form.RegisterGenerated((*foo)(nil)")
+		So(code, ShouldContainSubstring, "func formgenCheckinner(")
+		So(code, ShouldNotContainSubstring, "func formgenCheckbase(") //匿名嵌入的struct展开在foo中
+		So(code, ShouldNotContainSubstring, "Inner(")
+		So(code, ShouldNotContainSubstring, "Base(")
+		So(code, ShouldNotContainSubstring, "(*inner)(nil)")
+		So(code, ShouldNotContainSubstring, "(*base)(nil)")
+	})
+}
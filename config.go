@@ -0,0 +1,98 @@
+package form
+
+import (
+	"os"
+	"strings"
+)
+
+//BindEnv 从环境变量绑定配置并校验
+func BindEnv(o interface{}, prefix string) error {
+	return form.BindEnv(o, prefix)
+}
+
+//BindMap 从map绑定配置并校验
+func BindMap(o interface{}, m map[string]string) error {
+	return form.BindMap(o, m)
+}
+
+//BindEnv 从环境变量绑定配置，绑定后按valid tag校验所有字段。
+//环境变量名为prefix加上转换为大写的key，key中的.、[、]等符号替换为_，如prefix为APP_时db.host对应APP_DB_HOST。
+//slice字段只能读取一个值，需要用sep tag指定分隔符
+func (f *Form) BindEnv(o interface{}, prefix string) error {
+	return f.bindConfig(o, EnvSource(prefix))
+}
+
+//BindMap 从map绑定配置，如从配置文件读取的键值对，绑定后按valid tag校验所有字段
+func (f *Form) BindMap(o interface{}, m map[string]string) error {
+	return f.bindConfig(o, MapSource(m))
+}
+
+func (f *Form) bindConfig(o interface{}, src ValueSource) error {
+	if err := f.BindSource(o, src); err != nil {
+		return err
+	}
+	return f.CheckAllSource(o, src)
+}
+
+//EnvSource 读取环境变量，变量名的规则见BindEnv
+func EnvSource(prefix string) ValueSource {
+	return envSource(prefix)
+}
+
+type envSource string
+
+//name 返回key对应的环境变量名
+func (s envSource) name(key string) string {
+	var b strings.Builder
+	b.WriteString(string(s))
+	underscore := false
+	for _, r := range strings.ToUpper(key) {
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	return strings.TrimRight(b.String(), "_")
+}
+
+func (s envSource) Get(key string) string {
+	return os.Getenv(s.name(key))
+}
+
+func (s envSource) GetAll(key string) []string {
+	if value, ok := os.LookupEnv(s.name(key)); ok {
+		return []string{value}
+	}
+	return nil
+}
+
+func (s envSource) Has(key string) bool {
+	_, ok := os.LookupEnv(s.name(key))
+	return ok
+}
+
+//MapSource 读取map中的值
+func MapSource(m map[string]string) ValueSource {
+	return mapSource(m)
+}
+
+type mapSource map[string]string
+
+func (s mapSource) Get(key string) string {
+	return s[key]
+}
+
+func (s mapSource) GetAll(key string) []string {
+	if value, ok := s[key]; ok {
+		return []string{value}
+	}
+	return nil
+}
+
+func (s mapSource) Has(key string) bool {
+	_, ok := s[key]
+	return ok
+}
@@ -0,0 +1,25 @@
+//echoformvet 检查echo-form使用的struct tag，发现未知的规则、错误的规则参数、无法转换的默认值以及重复的key。
+//
+//安装：
+//
+//	go install github.com/jiazhoulvke/echo-form/echoformvet/cmd/echoformvet@latest
+//
+//用法：
+//
+//	echoformvet ./...
+//	echoformvet -rules=hello,even ./...
+//	go vet -vettool=$(which echoformvet) ./...
+//
+//-rules为通过AddCheckFunc或RegisterCheck注册的自定义检测器
+//
+//在echo-form仓库中开发时用go work init . ./echoformvet使用本地的echo-form
+package main
+
+import (
+	"github.com/jiazhoulvke/echo-form/echoformvet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(echoformvet.Analyzer)
+}
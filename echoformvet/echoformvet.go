@@ -0,0 +1,326 @@
+//Package echoformvet 定义了检查echo-form struct tag的go/analysis分析器。
+//
+//它与Form.Validate使用相同的解析和检查逻辑：规则是否存在、min、range等内置规则的参数、
+//引用的字段是否存在以及default能否转换为字段的类型，另外还检查嵌套和匿名嵌入的struct展开后是否有重复的key。
+//通过AddCheckFunc或RegisterCheck注册的检测器需要用-rules参数告诉分析器
+package echoformvet
+
+import (
+	"go/ast"
+	"go/types"
+	"mime/multipart"
+	"reflect"
+	"strings"
+	"time"
+
+	form "github.com/jiazhoulvke/echo-form"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+//Analyzer 检查struct的valid、default、form等tag
+var Analyzer = &analysis.Analyzer{
+	Name:     "echoformvet",
+	Doc:      "检查echo-form使用的struct tag：未知的规则、错误的规则参数、无法转换的默认值以及重复的key",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+//rules 自定义检测器的名字，以,分隔
+var rules string
+
+func init() {
+	Analyzer.Flags.StringVar(&rules, "rules", "", "以,分隔的自定义检测器名，即通过AddCheckFunc或RegisterCheck注册的检测器")
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	c := &checker{pass: pass, form: form.New()}
+	for _, name := range strings.Split(rules, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			c.form.RegisterCheck(name, func(form.Context) error { return nil })
+		}
+	}
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	ins.Preorder([]ast.Node{(*ast.TypeSpec)(nil)}, func(n ast.Node) {
+		ts := n.(*ast.TypeSpec)
+		if _, ok := ts.Type.(*ast.StructType); !ok || ts.TypeParams != nil {
+			return
+		}
+		obj := pass.TypesInfo.Defs[ts.Name]
+		if obj == nil {
+			return
+		}
+		st, ok := obj.Type().Underlying().(*types.Struct)
+		if !ok {
+			return
+		}
+		c.checkStruct(st, obj.Type(), ts.Name.Name, "")
+	})
+	return nil, nil
+}
+
+type checker struct {
+	pass *analysis.Pass
+	form *form.Form
+}
+
+//fieldKind 字段在绑定和校验时的处理方式，与form包中的一致
+type fieldKind int
+
+const (
+	plainField fieldKind = iota
+	nestedField
+	structPtrField
+	structSliceField
+)
+
+//checkStruct 检查struct中每个字段的tag以及展开后重复的key。parent为规则引用的同级字段所在的类型，
+//name为报错时使用的类型名，path为字段名的前缀。同一个包中有名字的struct在自己的声明处检查，这里只检查匿名的struct
+func (c *checker) checkStruct(st *types.Struct, parent types.Type, name, path string) {
+	hasField := func(field string) bool {
+		obj, _, _ := types.LookupFieldOrMethod(parent, true, c.pass.Pkg, field)
+		v, ok := obj.(*types.Var)
+		return ok && v.IsField()
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		sf := reflect.StructField{Name: v.Name(), Tag: reflect.StructTag(st.Tag(i)), Anonymous: v.Embedded()}
+		if c.form.TagInfo(sf).Skip {
+			continue
+		}
+		rt, exact, kind := c.fieldType(v.Type())
+		switch kind {
+		case nestedField, structPtrField:
+			elem := v.Type()
+			if kind == structPtrField {
+				elem = elem.Underlying().(*types.Pointer).Elem()
+			}
+			if nested, ok := elem.(*types.Struct); ok {
+				p := parent
+				if !v.Embedded() {
+					p = elem
+				}
+				c.checkStruct(nested, p, name, path+v.Name()+".")
+			}
+			continue
+		case structSliceField:
+			elem := v.Type().Underlying().(*types.Slice).Elem()
+			if nested, ok := elem.(*types.Struct); ok {
+				c.checkStruct(nested, elem, name, path+v.Name()+".")
+			}
+		}
+		sf.Type = rt
+		for _, e := range c.form.ValidateField(sf, hasField) {
+			if e.Tag == c.form.DefaultField && !exact {
+				//实现了UnmarshalText等无法在分析时转换的类型不检查默认值
+				continue
+			}
+			e.Type, e.Field = name, path+v.Name()
+			c.pass.Reportf(v.Pos(), "%s", e.Error())
+		}
+	}
+	if c.hasFormTags(st, map[*types.Struct]bool{}) {
+		c.checkKeys(st, name, path)
+	}
+}
+
+//keyField 展开后的字段
+type keyField struct {
+	//top 所在的当前struct的字段
+	top  *types.Var
+	path string
+}
+
+//checkKeys 检查struct展开后是否有重复的key。只报告由当前struct的不同字段带来的重复，
+//同一个字段内部的重复在嵌套struct自己的声明处报告
+func (c *checker) checkKeys(st *types.Struct, name, path string) {
+	keys := make(map[string]keyField)
+	for i := 0; i < st.NumFields(); i++ {
+		top := st.Field(i)
+		c.collectKeys(st, i, "", name+"."+path, func(key, fieldPath string) {
+			if prev, ok := keys[key]; ok {
+				if prev.top != top {
+					c.pass.Reportf(top.Pos(), "%s的key %q与%s重复", fieldPath, key, prev.path)
+				}
+				return
+			}
+			keys[key] = keyField{top: top, path: fieldPath}
+		}, map[*types.Struct]bool{})
+	}
+}
+
+//collectKeys 按绑定时的规则计算st中第i个字段展开后的key，嵌套struct以字段的key为前缀，匿名嵌入的struct没有前缀
+func (c *checker) collectKeys(st *types.Struct, i int, prefix, path string, add func(key, path string), seen map[*types.Struct]bool) {
+	v := st.Field(i)
+	if !v.Exported() && !v.Embedded() {
+		return
+	}
+	info := c.form.TagInfo(reflect.StructField{Name: v.Name(), Tag: reflect.StructTag(st.Tag(i)), Anonymous: v.Embedded()})
+	if info.Skip {
+		return
+	}
+	key := c.form.GenKey(prefix, info.Key)
+	path += v.Name()
+	_, _, kind := c.fieldType(v.Type())
+	var elem types.Type
+	switch kind {
+	case nestedField:
+		elem = v.Type()
+	case structPtrField:
+		elem = v.Type().Underlying().(*types.Pointer).Elem()
+	case structSliceField:
+		add(key, path)
+		elem = v.Type().Underlying().(*types.Slice).Elem()
+		key += "[]"
+	default:
+		add(key, path)
+		return
+	}
+	nested := elem.Underlying().(*types.Struct)
+	if seen[nested] {
+		return
+	}
+	seen[nested] = true
+	defer delete(seen, nested)
+	if v.Embedded() && kind != structSliceField {
+		key = prefix
+	}
+	for j := 0; j < nested.NumFields(); j++ {
+		c.collectKeys(nested, j, key, path+".", add, seen)
+	}
+}
+
+//hasFormTags struct展开后是否有echo-form使用的tag，只有json tag的struct不检查重复的key
+func (c *checker) hasFormTags(st *types.Struct, seen map[*types.Struct]bool) bool {
+	if seen[st] {
+		return false
+	}
+	seen[st] = true
+	names := []string{c.form.ValidField, c.form.DefaultField, c.form.FromField}
+	if len(c.form.FormFields) > 0 {
+		names = append(names, c.form.FormFields[0])
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		tag := reflect.StructTag(st.Tag(i))
+		for _, name := range names {
+			if _, ok := tag.Lookup(name); ok {
+				return true
+			}
+		}
+		if _, _, kind := c.fieldType(st.Field(i).Type()); kind != plainField {
+			elem := st.Field(i).Type().Underlying()
+			switch t := elem.(type) {
+			case *types.Pointer:
+				elem = t.Elem().Underlying()
+			case *types.Slice:
+				elem = t.Elem().Underlying()
+			}
+			if c.hasFormTags(elem.(*types.Struct), seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	durationType  = reflect.TypeOf(time.Duration(0))
+	fileType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+	//anyType 无法对应的类型，内置规则对它的检查与运行时一样会报不支持
+	anyType     = reflect.TypeOf((*interface{})(nil)).Elem()
+	structSlice = reflect.TypeOf([]struct{}(nil))
+)
+
+var basicTypes = map[types.BasicKind]reflect.Type{
+	types.Bool:    reflect.TypeOf(false),
+	types.Int:     reflect.TypeOf(int(0)),
+	types.Int8:    reflect.TypeOf(int8(0)),
+	types.Int16:   reflect.TypeOf(int16(0)),
+	types.Int32:   reflect.TypeOf(int32(0)),
+	types.Int64:   reflect.TypeOf(int64(0)),
+	types.Uint:    reflect.TypeOf(uint(0)),
+	types.Uint8:   reflect.TypeOf(uint8(0)),
+	types.Uint16:  reflect.TypeOf(uint16(0)),
+	types.Uint32:  reflect.TypeOf(uint32(0)),
+	types.Uint64:  reflect.TypeOf(uint64(0)),
+	types.Float32: reflect.TypeOf(float32(0)),
+	types.Float64: reflect.TypeOf(float64(0)),
+	types.String:  reflect.TypeOf(""),
+}
+
+//fieldType 返回与字段类型对应的reflect.Type，exact表示转换结果与运行时完全一致，可以检查默认值
+func (c *checker) fieldType(t types.Type) (rt reflect.Type, exact bool, kind fieldKind) {
+	switch {
+	case isNamed(t, "time", "Time"):
+		return timeType, true, plainField
+	case isNamed(t, "time", "Duration"):
+		return durationType, true, plainField
+	case isFile(t):
+		return fileType, true, plainField
+	}
+	if isTextUnmarshaler(t) {
+		if b, ok := t.Underlying().(*types.Basic); ok && basicTypes[b.Kind()] != nil {
+			return basicTypes[b.Kind()], false, plainField
+		}
+		return anyType, false, plainField
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		if rt, ok := basicTypes[u.Kind()]; ok {
+			return rt, true, plainField
+		}
+	case *types.Struct:
+		if c.isNestedStruct(t) {
+			return anyType, false, nestedField
+		}
+	case *types.Pointer:
+		if c.isNestedStruct(u.Elem()) {
+			return anyType, false, structPtrField
+		}
+		rt, exact, _ := c.fieldType(u.Elem())
+		return reflect.PtrTo(rt), exact, plainField
+	case *types.Slice:
+		if c.isNestedStruct(u.Elem()) {
+			return structSlice, false, structSliceField
+		}
+		if isFile(u.Elem()) {
+			return fileSliceType, true, plainField
+		}
+		rt, exact, _ := c.fieldType(u.Elem())
+		return reflect.SliceOf(rt), exact, plainField
+	}
+	return anyType, false, plainField
+}
+
+//isNestedStruct 是否为逐个字段绑定的struct，与Form.isNestedStruct一致
+func (c *checker) isNestedStruct(t types.Type) bool {
+	if _, ok := t.Underlying().(*types.Struct); !ok {
+		return false
+	}
+	return !isNamed(t, "time", "Time") && !isNamed(t, "mime/multipart", "FileHeader") && !isTextUnmarshaler(t)
+}
+
+func isNamed(t types.Type, pkg, name string) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == pkg && obj.Name() == name
+}
+
+func isFile(t types.Type) bool {
+	ptr, ok := t.(*types.Pointer)
+	return ok && isNamed(ptr.Elem(), "mime/multipart", "FileHeader")
+}
+
+//isTextUnmarshaler 类型或其指针是否有UnmarshalText方法
+func isTextUnmarshaler(t types.Type) bool {
+	if _, ok := t.(*types.Pointer); !ok {
+		t = types.NewPointer(t)
+	}
+	return types.NewMethodSet(t).Lookup(nil, "UnmarshalText") != nil
+}
@@ -0,0 +1,15 @@
+package echoformvet
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	if err := Analyzer.Flags.Set("rules", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	defer Analyzer.Flags.Set("rules", "")
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a")
+}
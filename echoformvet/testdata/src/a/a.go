@@ -0,0 +1,67 @@
+package a
+
+import (
+	"mime/multipart"
+	"strings"
+	"time"
+)
+
+type Status int
+
+func (s *Status) UnmarshalText(text []byte) error {
+	*s = Status(len(text))
+	return nil
+}
+
+type Base struct {
+	ID   int64  `form:"id" valid:"required"`
+	Name string `form:"name"`
+}
+
+type Address struct {
+	City string `form:"city" valid:"requird"` // want `Address.City的valid tag错误:requird:检测器requird找不到`
+}
+
+type Order struct {
+	Base
+	Name     string                `form:"name"`                                               // want `Order.Name的key "name"与Order.Base.Name重复`
+	Age      int                   `form:"age" valid:"range:5"`                                // want `Order.Age的valid tag错误:range:需要2个参数`
+	Weight   float64               `form:"weight" valid:"min:a"`                               // want `Order.Weight的valid tag错误:min:参数"a"错误`
+	Quota    int64                 `form:"quota" unit:"bytes" valid:"max:100MB" default:"1XB"` // want `Order.Quota的default tag错误:"1XB"不能转换为int64`
+	Limit    int64                 `form:"limit" unit:"bytes" valid:"max:-1MB"`                // want `Order.Limit的valid tag错误:max:参数"-1MB"错误:大小不能为负数`
+	Timeout  time.Duration         `form:"timeout" default:"30s" valid:"max:1h"`
+	Created  time.Time             `form:"created" default:"yesterday"`     // want `Order.Created的default tag错误`
+	Count    *uint8                `form:"count" default:"300"`             // want `Order.Count的default tag错误`
+	Paid     bool                  `form:"paid" valid:"max:1"`              // want `Order.Paid的valid tag错误:max:未支持格式bool`
+	Confirm  string                `form:"confirm" valid:"eqfield:Pasword"` // want `Order.Confirm的valid tag错误:eqfield:找不到字段Pasword`
+	Invoice  string                `form:"invoice" valid:"required_if:Paid,true;required_with:ID"`
+	Avatar   *multipart.FileHeader `form:"avatar" valid:"filesize:2MB;mimetype:image/*"`
+	Docs     []string              `form:"docs" valid:"ext:pdf"` // want `Order.Docs的valid tag错误:ext:只能用于上传文件`
+	Status   Status                `form:"status" default:"anything" valid:"range:1,3"`
+	Tags     []int                 `form:"tags" valid:"max:3" default:"1,a"` // want `Order.Tags的default tag错误`
+	Hello    string                `form:"hello" valid:"hello:x"`
+	Secret   string                `form:"-" valid:"requird"`
+	Shipping Address               `form:"shipping"`
+	Billing  struct {
+		City string `form:"city" valid:"max:x"` // want `Order.Billing.City的valid tag错误:max:参数"x"错误`
+		Zip  string `form:"zip"`
+		Code string `form:"zip"` // want `Order.Billing.Code的key "zip"与Order.Billing.Zip重复`
+	} `form:"billing"`
+	Items []struct { // want `Order.Items的valid tag错误:max:参数"a"错误`
+		Qty int `form:"qty" valid:"min:1" default:"one"` // want `Order.Items.Qty的default tag错误`
+	} `form:"items" valid:"max:a"`
+	Builder strings.Builder `form:"builder"`
+}
+
+type Plain struct {
+	Name  string `json:"name"`
+	Alias string `json:"name"`
+}
+
+type Nested struct {
+	Shipping Address `form:"shipping"`
+	Billing  Address `form:"billing"`
+	*Base
+	Title  string `form:"title" valid:"required_without:Name"`
+	Coupon string `form:"coupon" valid:"required_unless:channel,web,app"`
+}
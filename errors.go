@@ -0,0 +1,75 @@
+package form
+
+import (
+	"strings"
+)
+
+//FieldError 字段校验错误
+type FieldError struct {
+	//Field 字段名
+	Field string `json:"field"`
+	//Key 表单中的key
+	Key string `json:"key"`
+	//Title 显示的标题
+	Title string `json:"title"`
+	//Rule 校验失败的规则名
+	Rule string `json:"rule"`
+	//Params 规则的参数
+	Params []string `json:"params,omitempty"`
+	//Value 输入的值
+	Value string `json:"value"`
+	//Message 错误信息
+	Message string `json:"message"`
+	//Err 转换失败时的原始错误，如UnmarshalText返回的错误
+	Err error `json:"-"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+//Unwrap 返回原始错误
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+//newFieldError 将检测器返回的错误转为FieldError
+func newFieldError(err error) *FieldError {
+	if fe, ok := err.(*FieldError); ok {
+		return fe
+	}
+	return &FieldError{Message: err.Error()}
+}
+
+//fill 填充检测器没有设置的字段
+func (e *FieldError) fill(field, key, title, rule string, params []string, value string) {
+	if e.Field == "" {
+		e.Field = field
+	}
+	if e.Key == "" {
+		e.Key = key
+	}
+	if e.Title == "" {
+		e.Title = title
+	}
+	if e.Rule == "" {
+		e.Rule = rule
+	}
+	if e.Params == nil {
+		e.Params = params
+	}
+	if e.Value == "" {
+		e.Value = value
+	}
+}
+
+//ValidationErrors 所有校验失败的字段
+type ValidationErrors []*FieldError
+
+func (es ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Message)
+	}
+	return strings.Join(msgs, ";")
+}
@@ -1,11 +1,14 @@
 package form
 
 import (
+	"encoding"
 	"fmt"
 	"math"
+	"mime/multipart"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -20,10 +23,46 @@ var (
 	ValidField = "valid"
 	//DefaultField 默认值tag
 	DefaultField = "default"
+	//MessageField 自定义错误信息tag，格式为"规则名=错误信息;规则名=错误信息"
+	MessageField = "msg"
+	//SepField slice字段的分隔符tag，设置后每个值再按分隔符拆分
+	SepField = "sep"
+	//TimeFormatField time.Time字段的时间格式tag，如time_format:"2006/01/02"
+	TimeFormatField = "time_format"
+	//TimeUnitField time.Time字段的时间戳单位tag，可以为s、ms、us、ns
+	TimeUnitField = "time_unit"
+	//UnitField 整数字段的单位tag，为bytes时可以输入10MB这样带单位的大小
+	UnitField = "unit"
+	//FromField 值的来源tag，可以为form、query、param、header、cookie，多个来源以,分隔时依次读取
+	FromField = "from"
+	//Sources 字段没有设置来源时依次读取的来源
+	Sources = []string{SourceForm}
+	//TimeLayouts 默认尝试的时间格式
+	TimeLayouts = []string{dateLayout, timeLayout, time.RFC3339}
 )
 
 var form = New()
 
+//KeyStyle 嵌套struct中字段的key格式
+type KeyStyle int
+
+const (
+	//DotKey 如shipping.city
+	DotKey KeyStyle = iota
+	//BracketKey 如shipping[city]
+	BracketKey
+)
+
+//CheckMode 校验模式
+type CheckMode int
+
+const (
+	//CheckInput 校验请求中的原始输入
+	CheckInput CheckMode = iota
+	//CheckValue 校验struct中已有的值，可以在任意binder之后使用，也可以不传echo.Context
+	CheckValue
+)
+
 //Bind 绑定数据
 func Bind(ctx echo.Context, o interface{}) error {
 	return form.Bind(o, ctx)
@@ -34,12 +73,98 @@ func Check(ctx echo.Context, o interface{}) error {
 	return form.Check(o, ctx)
 }
 
+//CheckAll 检测数据，返回所有校验失败的字段
+func CheckAll(ctx echo.Context, o interface{}) error {
+	return form.CheckAll(o, ctx)
+}
+
+//BindSource 从任意ValueSource绑定数据
+func BindSource(src ValueSource, o interface{}) error {
+	return form.BindSource(o, src)
+}
+
+//CheckSource 检测从任意ValueSource读取的数据
+func CheckSource(src ValueSource, o interface{}) error {
+	return form.CheckSource(o, src)
+}
+
+//CheckAllSource 检测从任意ValueSource读取的数据，返回所有校验失败的字段
+func CheckAllSource(src ValueSource, o interface{}) error {
+	return form.CheckAllSource(o, src)
+}
+
 //Form form
 type Form struct {
-	FormFields   []string
-	LabelFields  []string
-	ValidField   string
-	DefaultField string
+	FormFields      []string
+	LabelFields     []string
+	ValidField      string
+	DefaultField    string
+	MessageField    string
+	SepField        string
+	TimeFormatField string
+	TimeUnitField   string
+	UnitField       string
+	FromField       string
+	//Sources 字段没有设置FromField时依次读取的来源
+	Sources []string
+	//TimeLayouts 没有指定时间格式时依次尝试的格式
+	TimeLayouts []string
+	//Location 解析没有时区的时间时使用的时区，为nil时时间按UTC解析、时间戳按本地时区
+	Location *time.Location
+	//CheckMode 校验模式，默认为CheckInput
+	CheckMode CheckMode
+	//KeyStyle 嵌套struct中字段的key格式，默认为DotKey
+	KeyStyle KeyStyle
+	//Translator 错误信息翻译器，为nil时使用DefaultCatalog
+	Translator Translator
+	//Lang 请求的Accept-Language中没有可用的语言时使用的语言
+	Lang string
+	//NoGenerated 为true时不使用echo-formgen生成的代码
+	NoGenerated bool
+
+	convertersMu sync.RWMutex
+	converters   map[reflect.Type]ConvertFunc
+	checksMu     sync.RWMutex
+	//checks 只在当前Form中使用的检测器
+	checks map[string]CheckFunc
+	//builtinOverridden 不为0时checks中有覆盖内置检测器的，生成的校验代码不再使用
+	builtinOverridden int32
+	//cache 以reflect.Type为key缓存的*structMeta
+	cache sync.Map
+}
+
+//ConvertFunc 将表单输入转换为指定类型的值
+type ConvertFunc func(input string) (reflect.Value, error)
+
+//RegisterConverter 注册类型t的转换函数，用于绑定无法添加方法的第三方类型，如decimal、uuid。
+//绑定时优先于内置的转换，注册了的struct类型不再逐个字段绑定。可以并发调用，
+//注册时会清空字段信息的缓存，以便已经使用过的类型按新的转换函数重新解析，因此最好在使用Form之前注册
+func (f *Form) RegisterConverter(t reflect.Type, fn ConvertFunc) {
+	f.convertersMu.Lock()
+	if f.converters == nil {
+		f.converters = make(map[reflect.Type]ConvertFunc)
+	}
+	f.converters[t] = fn
+	f.convertersMu.Unlock()
+	f.cache.Range(func(key, _ interface{}) bool {
+		f.cache.Delete(key)
+		return true
+	})
+}
+
+//converter 返回类型t注册的转换函数
+func (f *Form) converter(t reflect.Type) (ConvertFunc, bool) {
+	f.convertersMu.RLock()
+	fn, ok := f.converters[t]
+	f.convertersMu.RUnlock()
+	return fn, ok
+}
+
+//hasConverters 是否注册了转换函数
+func (f *Form) hasConverters() bool {
+	f.convertersMu.RLock()
+	defer f.convertersMu.RUnlock()
+	return len(f.converters) > 0
 }
 
 //OptionsFunc 设置
@@ -48,10 +173,19 @@ type OptionsFunc func(*Form)
 //New new form
 func New(fns ...OptionsFunc) *Form {
 	form := Form{
-		FormFields:   FormFields,
-		LabelFields:  LabelFields,
-		ValidField:   ValidField,
-		DefaultField: DefaultField,
+		FormFields:      FormFields,
+		LabelFields:     LabelFields,
+		ValidField:      ValidField,
+		DefaultField:    DefaultField,
+		MessageField:    MessageField,
+		SepField:        SepField,
+		TimeFormatField: TimeFormatField,
+		TimeUnitField:   TimeUnitField,
+		UnitField:       UnitField,
+		FromField:       FromField,
+		Sources:         Sources,
+		TimeLayouts:     TimeLayouts,
+		Lang:            DefaultLang,
 	}
 	for _, fn := range fns {
 		fn(&form)
@@ -61,6 +195,48 @@ func New(fns ...OptionsFunc) *Form {
 
 //Check 检测数据
 func (f *Form) Check(o interface{}, ctx echo.Context) error {
+	return f.CheckSource(o, EchoSource(ctx))
+}
+
+//CheckSource 检测从src读取的数据，CheckValue模式下src可以为nil
+func (f *Form) CheckSource(o interface{}, src ValueSource) error {
+	t, v, err := checkTarget(o)
+	if err != nil {
+		return err
+	}
+	if g, ok := f.lookupGenerated(o); ok && g.Check != nil && f.useGeneratedCheck() {
+		return g.Check(f, o, src, nil)
+	}
+	return f.checkStruct(t, v, v, "", src, nil)
+}
+
+//CheckAll 检测所有字段的所有规则，有字段校验失败时返回ValidationErrors
+func (f *Form) CheckAll(o interface{}, ctx echo.Context) error {
+	return f.CheckAllSource(o, EchoSource(ctx))
+}
+
+//CheckAllSource 同CheckAll，从src读取数据
+func (f *Form) CheckAllSource(o interface{}, src ValueSource) error {
+	t, v, err := checkTarget(o)
+	if err != nil {
+		return err
+	}
+	var errs ValidationErrors
+	if g, ok := f.lookupGenerated(o); ok && g.Check != nil && f.useGeneratedCheck() {
+		err = g.Check(f, o, src, &errs)
+	} else {
+		err = f.checkStruct(t, v, v, "", src, &errs)
+	}
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func checkTarget(o interface{}) (reflect.Type, reflect.Value, error) {
 	t := reflect.TypeOf(o)
 	v := reflect.ValueOf(o)
 	if t.Kind() == reflect.Ptr {
@@ -68,50 +244,160 @@ func (f *Form) Check(o interface{}, ctx echo.Context) error {
 		v = v.Elem()
 	}
 	if t.Kind() != reflect.Struct {
-		return fmt.Errorf("参数必须为struct")
+		return nil, reflect.Value{}, fmt.Errorf("参数必须为struct")
 	}
-	return f.checkStruct(t, v, ctx)
+	return t, v, nil
 }
 
-func (f *Form) checkStruct(t reflect.Type, v reflect.Value, ctx echo.Context) error {
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		value := v.Field(i)
-		if field.Type.Kind() == reflect.Struct {
-			if err := f.checkStruct(field.Type, value, ctx); err != nil {
+//checkStruct parent为字段所在的struct，prefix为字段key的前缀，嵌入的struct与外层共用parent和prefix。
+//errs为nil时遇到第一个错误即返回，否则将校验错误收集到errs中
+func (f *Form) checkStruct(t reflect.Type, v reflect.Value, parent reflect.Value, prefix string, src ValueSource, errs *ValidationErrors) error {
+	for _, fm := range f.structMeta(t).fields {
+		field := fm.field
+		value := v.Field(fm.index)
+		switch fm.kind {
+		case nestedField:
+			p, pre := value, f.joinKey(prefix, fm.name)
+			if field.Anonymous {
+				p, pre = parent, prefix
+			}
+			if err := f.checkStruct(field.Type, value, p, pre, src, errs); err != nil {
 				return err
 			}
-		} else {
-			if err := f.checkField(field, value, ctx); err != nil {
+		case structPtrField:
+			//指针为nil(CheckInput模式下为请求中没有对应的key)时不校验
+			elemType := field.Type.Elem()
+			p, pre := value, f.joinKey(prefix, fm.name)
+			if field.Anonymous {
+				p, pre = parent, prefix
+			}
+			var elem reflect.Value
+			if f.CheckMode == CheckValue {
+				if value.IsNil() {
+					continue
+				}
+				elem = value.Elem()
+			} else {
+				if !f.hasInput(elemType, pre, src) {
+					continue
+				}
+				if value.IsNil() {
+					elem = reflect.New(elemType).Elem()
+				} else {
+					elem = value.Elem()
+				}
+			}
+			if !field.Anonymous {
+				p = elem
+			}
+			if err := f.checkStruct(elemType, elem, p, pre, src, errs); err != nil {
 				return err
 			}
+		case structSliceField:
+			if err := f.checkField(fm, value, parent, prefix, src, errs); err != nil {
+				return err
+			}
+			if err := f.checkSlice(field.Type.Elem(), value, f.joinKey(prefix, fm.name), src, errs); err != nil {
+				return err
+			}
+		default:
+			if err := f.checkField(fm, value, parent, prefix, src, errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//checkSlice 校验struct slice中的每个元素，CheckInput模式下元素个数由请求中的key决定
+func (f *Form) checkSlice(t reflect.Type, v reflect.Value, prefix string, src ValueSource, errs *ValidationErrors) error {
+	var n int
+	if f.CheckMode == CheckValue {
+		n = v.Len()
+	} else {
+		n = f.countStructs(t, prefix, src)
+	}
+	for i := 0; i < n; i++ {
+		var elem reflect.Value
+		if i < v.Len() {
+			elem = v.Index(i)
+		} else {
+			elem = reflect.New(t).Elem()
+		}
+		if err := f.checkStruct(t, elem, elem, f.indexKey(prefix, i), src, errs); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func (f *Form) checkField(t reflect.StructField, v reflect.Value, ctx echo.Context) error {
-	title := defaultField(t, f.LabelFields)
-	input := ctx.FormValue(defaultField(t, f.FormFields))
-	rules := f.parseRules(t)
-	for _, r := range rules {
+func (f *Form) checkField(fm *fieldMeta, v reflect.Value, parent reflect.Value, prefix string, src ValueSource, errs *ValidationErrors) error {
+	t := fm.field
+	title := fm.title
+	key := f.joinKey(prefix, fm.name)
+	var input string
+	//zero CheckValue模式下非指针字段为数值的零值，只有required将其视为空
+	var zero bool
+	if f.CheckMode == CheckValue {
+		input = f.valueString(v)
+		if input == "" && isNumberKind(v.Kind()) {
+			//min、max、range以及字段比较需要看到实际的数值0
+			input, zero = f.formatValue(v), true
+		}
+	} else if fm.kind == fileField {
+		names := make([]string, 0, 1)
+		for _, fh := range requestFiles(src, key) {
+			names = append(names, fh.Filename)
+		}
+		input = strings.Join(names, ",")
+	} else if fm.kind == structSliceField {
+		if n := f.countStructs(t.Type.Elem(), key, src); n > 0 {
+			input = strconv.Itoa(n)
+		}
+	} else if fm.multi {
+		input = strings.Join(f.sliceInputs(fm, key, src), ",")
+	} else {
+		input = f.formValue(fm, key, src)
+	}
+	if t.Type.Kind() == reflect.Ptr && fm.kind != fileField {
+		//检测器看到的是指针指向的类型，nil视为零值
+		v = indirect(v)
+		t.Type = t.Type.Elem()
+	}
+	for _, r := range fm.rules {
 		if r.Name == "" {
 			continue
 		}
-		checkFunc, ok := checkers[r.Name]
+		checkFunc, ok := f.checkFunc(r.Name)
 		if !ok {
 			return fmt.Errorf("检测器%s找不到", r.Name)
 		}
 		c := Context{
 			Input:  input,
+			Key:    key,
 			Title:  title,
 			Params: r.Params,
+			limits: r.limits,
 			Field:  t,
 			Value:  v,
-			Ctx:    ctx,
+			Parent: parent,
+			Source: src,
+			Ctx:    echoContext(src),
+			form:   f,
+			meta:   fm,
+			prefix: prefix,
+			zero:   zero,
 		}
 		if err := checkFunc(c); err != nil {
-			return err
+			fe := newFieldError(err)
+			fe.fill(t.Name, key, title, r.Name, r.Params, input)
+			if msg, ok := fm.messages[fe.Rule]; ok {
+				fe.Message = msg
+			}
+			if errs == nil {
+				return fe
+			}
+			*errs = append(*errs, fe)
 		}
 	}
 	return nil
@@ -119,6 +405,11 @@ func (f *Form) checkField(t reflect.StructField, v reflect.Value, ctx echo.Conte
 
 //Bind 绑定表单值
 func (f *Form) Bind(o interface{}, ctx echo.Context) error {
+	return f.BindSource(o, EchoSource(ctx))
+}
+
+//BindSource 绑定从src读取的值
+func (f *Form) BindSource(o interface{}, src ValueSource) error {
 	t := reflect.TypeOf(o)
 	v := reflect.ValueOf(o)
 	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
@@ -127,271 +418,636 @@ func (f *Form) Bind(o interface{}, ctx echo.Context) error {
 	} else {
 		return fmt.Errorf("参数必须为struct的指针")
 	}
-	return f.bindStruct(t, v, ctx)
+	if g, ok := f.lookupGenerated(o); ok && g.Bind != nil {
+		return g.Bind(f, o, src)
+	}
+	return f.bindStruct(t, v, "", src)
 }
 
-func (f *Form) bindStruct(t reflect.Type, v reflect.Value, ctx echo.Context) error {
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		value := v.Field(i)
-		typeName := ""
-		if value.CanInterface() {
-			typeName = reflect.TypeOf(value.Interface()).String()
-		}
-		if field.Type.Kind() == reflect.Struct && typeName != "time.Time" {
-			if err := f.bindStruct(field.Type, value, ctx); err != nil {
+//bindStruct prefix为字段key的前缀，嵌入的struct与外层共用prefix
+func (f *Form) bindStruct(t reflect.Type, v reflect.Value, prefix string, src ValueSource) error {
+	for _, fm := range f.structMeta(t).fields {
+		field := fm.field
+		value := v.Field(fm.index)
+		switch fm.kind {
+		case nestedField:
+			pre := prefix
+			if !field.Anonymous {
+				pre = f.joinKey(prefix, fm.name)
+			}
+			if err := f.bindStruct(field.Type, value, pre, src); err != nil {
 				return err
 			}
-		} else {
-			if err := f.bindField(field, value, ctx); err != nil {
+		case fileField:
+			f.bindFile(fm, value, prefix, src)
+		case structPtrField:
+			pre := prefix
+			if !field.Anonymous {
+				pre = f.joinKey(prefix, fm.name)
+			}
+			if value.IsNil() {
+				if !value.CanSet() || !f.hasInput(field.Type.Elem(), pre, src) {
+					continue
+				}
+				value.Set(reflect.New(field.Type.Elem()))
+			}
+			if err := f.bindStruct(field.Type.Elem(), value.Elem(), pre, src); err != nil {
+				return err
+			}
+		case structSliceField:
+			if err := f.bindSlice(field.Type, value, f.joinKey(prefix, fm.name), src); err != nil {
 				return err
 			}
+		default:
+			if err := f.bindField(fm, value, prefix, src); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//bindSlice 绑定struct slice，key的格式为items[0].name，下标必须从0开始连续
+func (f *Form) bindSlice(t reflect.Type, v reflect.Value, prefix string, src ValueSource) error {
+	n := f.countStructs(t.Elem(), prefix, src)
+	if n == 0 || !v.CanSet() {
+		return nil
+	}
+	slice := reflect.MakeSlice(t, n, n)
+	for i := 0; i < n; i++ {
+		if i < v.Len() {
+			slice.Index(i).Set(v.Index(i))
+		}
+		if err := f.bindStruct(t.Elem(), slice.Index(i), f.indexKey(prefix, i), src); err != nil {
+			return err
 		}
 	}
+	v.Set(slice)
 	return nil
 }
 
+//countStructs 返回请求中struct slice的元素个数
+func (f *Form) countStructs(t reflect.Type, prefix string, src ValueSource) int {
+	n := 0
+	for f.hasInput(t, f.indexKey(prefix, n), src) {
+		n++
+	}
+	return n
+}
+
+//maxDepth hasInput查找嵌套struct的最大层数，避免自引用的类型无限递归
+const maxDepth = 16
+
+//hasInput 请求中是否有struct t中任意字段的值
+func (f *Form) hasInput(t reflect.Type, prefix string, src ValueSource) bool {
+	return f.hasInputDepth(t, prefix, src, 0)
+}
+
+func (f *Form) hasInputDepth(t reflect.Type, prefix string, src ValueSource, depth int) bool {
+	if src == nil || depth > maxDepth {
+		return false
+	}
+	for _, fm := range f.structMeta(t).fields {
+		field := fm.field
+		key := f.joinKey(prefix, fm.name)
+		switch fm.kind {
+		case nestedField, structPtrField:
+			if field.Anonymous {
+				key = prefix
+			}
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if f.hasInputDepth(ft, key, src, depth+1) {
+				return true
+			}
+		case fileField:
+			if len(requestFiles(src, key)) > 0 {
+				return true
+			}
+		case structSliceField:
+			if f.hasInputDepth(field.Type.Elem(), f.indexKey(key, 0), src, depth+1) {
+				return true
+			}
+		default:
+			if f.hasValue(fm, key, src) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//isStructPtr 是否为指向嵌套struct的指针
+func (f *Form) isStructPtr(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && f.isNestedStruct(t.Elem())
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+//isTextType 是否为实现了encoding.TextUnmarshaler的类型(time.Time除外)，这类字段直接用UnmarshalText绑定
+func isTextType(t reflect.Type) bool {
+	if t == timeType {
+		return false
+	}
+	return t.Implements(textUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+//isCustomType 是否为注册了转换函数或实现了encoding.TextUnmarshaler的类型，这类字段作为一个整体绑定
+func (f *Form) isCustomType(t reflect.Type) bool {
+	if _, ok := f.converter(t); ok {
+		return true
+	}
+	return isTextType(t)
+}
+
+//isNestedStruct 是否为需要逐个字段绑定和校验的struct，time.Time、上传文件以及isCustomType的类型除外
+func (f *Form) isNestedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType && t != fileType.Elem() && !f.isCustomType(t)
+}
+
+var (
+	fileType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+//isFileType 是否为上传文件的类型，即*multipart.FileHeader或[]*multipart.FileHeader
+func isFileType(t reflect.Type) bool {
+	return t == fileType || t == fileSliceType
+}
+
+//bindFile 绑定上传文件
+func (f *Form) bindFile(fm *fieldMeta, v reflect.Value, prefix string, src ValueSource) {
+	files := requestFiles(src, f.joinKey(prefix, fm.name))
+	if len(files) == 0 || !v.CanSet() {
+		return
+	}
+	if fm.field.Type == fileType {
+		v.Set(reflect.ValueOf(files[0]))
+	} else {
+		v.Set(reflect.ValueOf(files))
+	}
+}
+
+//isStructSlice 是否为元素是嵌套struct的slice
+func (f *Form) isStructSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && f.isNestedStruct(t.Elem())
+}
+
 const (
 	dateLayout = "2006-01-02"
 	timeLayout = "2006-01-02 15:04:05"
 )
 
-func (f *Form) bindField(field reflect.StructField, v reflect.Value, ctx echo.Context) error {
-	title := defaultField(field, f.LabelFields)
-	input := ctx.FormValue(defaultField(field, f.FormFields))
-	defaultStr := field.Tag.Get(f.DefaultField)
-	if input == "" && defaultStr == "" {
+var timeType = reflect.TypeOf(time.Time{})
+
+//valueString 将字段的值转为检测器使用的字符串，零值和nil转为空字符串，slice的元素以,连接，struct slice转为元素个数。
+//指针不为nil时即使指向零值也会转为非空字符串
+func (f *Form) valueString(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		return f.formatValue(v.Elem())
+	}
+	if v.IsZero() {
+		return ""
+	}
+	return f.formatValue(v)
+}
+
+//isNumberKind 是否为整数或浮点数
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+//formatValue 将值转为字符串，不对零值做特殊处理
+func (f *Form) formatValue(v reflect.Value) string {
+	if v.Type() != timeType && v.CanInterface() {
+		if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+			if text, err := m.MarshalText(); err == nil {
+				return string(text)
+			}
+		}
+		if s, ok := v.Interface().(fmt.Stringer); ok && v.Kind() == reflect.Struct {
+			return s.String()
+		}
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Slice:
+		if f.isStructSlice(v.Type()) {
+			if v.Len() > 0 {
+				return strconv.Itoa(v.Len())
+			}
+			return ""
+		}
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, f.formatValue(v.Index(i)))
+		}
+		return strings.Join(items, ",")
+	case reflect.Ptr:
+		return f.valueString(v)
+	case reflect.Struct:
+		if v.Type() == timeType && v.CanInterface() {
+			return v.Interface().(time.Time).Format(time.RFC3339Nano)
+		}
+		if v.Type() == fileType.Elem() {
+			return v.FieldByName("Filename").String()
+		}
+	}
+	return ""
+}
+
+func (f *Form) bindField(fm *fieldMeta, v reflect.Value, prefix string, src ValueSource) error {
+	field := fm.field
+	title := fm.title
+	key := f.joinKey(prefix, fm.name)
+	var inputs []string
+	if fm.multi {
+		inputs = f.sliceInputs(fm, key, src)
+	} else if input := f.formValue(fm, key, src); input != "" {
+		inputs = []string{input}
+	}
+	if len(inputs) == 0 && !fm.multi && field.Type.Kind() == reflect.Ptr && f.hasValue(fm, key, src) {
+		//指针字段的key存在但值为空时指向零值，与没有传区分
+		if v.CanSet() {
+			v.Set(reflect.New(field.Type.Elem()))
+		}
 		return nil
 	}
-	if input == "" {
-		input = defaultStr
+	if len(inputs) == 0 {
+		if fm.defaultValue == "" {
+			return nil
+		}
+		if fm.multi {
+			sep := fm.sep
+			if sep == "" {
+				sep = ","
+			}
+			inputs = strings.Split(fm.defaultValue, sep)
+		} else {
+			inputs = []string{fm.defaultValue}
+		}
 	}
 	if !v.CanSet() {
 		return nil
 	}
+	if fm.multi {
+		v.Set(reflect.MakeSlice(field.Type, 0, len(inputs)))
+	}
+	for _, input := range inputs {
+		if err := f.setValue(field, v, input); err != nil {
+			return f.convertError(src, field.Name, key, title, input, fm.messages, err)
+		}
+	}
+	return nil
+}
+
+//convertError 将转换失败时的ruleError包装为FieldError，其他错误原样返回
+func (f *Form) convertError(src ValueSource, field, key, title, input string, messages map[string]string, err error) error {
+	re, ok := err.(ruleError)
+	if !ok {
+		return err
+	}
+	msg, ok := messages[re.rule]
+	if !ok {
+		msg = f.translate(src, re.rule, title)
+	}
+	return &FieldError{
+		Field:   field,
+		Key:     key,
+		Title:   title,
+		Rule:    re.rule,
+		Value:   input,
+		Message: msg,
+		Err:     re.err,
+	}
+}
+
+//sliceInputs 返回slice字段在请求中的所有值，同一个key可以出现多次。
+//设置了SepField时每个值再按分隔符拆分
+func (f *Form) sliceInputs(fm *fieldMeta, key string, src ValueSource) []string {
+	if src == nil {
+		return nil
+	}
+	values := f.formValues(fm, key, src)
+	sep := fm.sep
+	inputs := make([]string, 0, len(values))
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		if sep == "" {
+			inputs = append(inputs, value)
+			continue
+		}
+		for _, item := range strings.Split(value, sep) {
+			if item != "" {
+				inputs = append(inputs, item)
+			}
+		}
+	}
+	return inputs
+}
+
+//ruleError 转换失败时对应的规则名以及原始错误
+type ruleError struct {
+	rule string
+	err  error
+}
+
+func (e ruleError) Error() string {
+	if e.err != nil {
+		return e.rule + ":" + e.err.Error()
+	}
+	return e.rule
+}
+
+//setValue 将input转换为字段的类型后赋值给v，slice则转换为元素的类型后追加到v中，指针则分配新的值。
+//注册了转换函数的类型优先使用转换函数。转换失败时返回ruleError
+func (f *Form) setValue(field reflect.StructField, v reflect.Value, input string) error {
+	if fn, ok := f.converter(field.Type); ok {
+		value, err := fn(input)
+		if err != nil {
+			return ruleError{rule: "format", err: err}
+		}
+		if !value.IsValid() || !value.Type().ConvertibleTo(field.Type) {
+			return fmt.Errorf("%s的转换函数返回了错误的类型", field.Type)
+		}
+		v.Set(value.Convert(field.Type))
+		return nil
+	}
+	if field.Type.Kind() == reflect.Ptr {
+		elem := reflect.StructField{
+			Name: field.Name,
+			Type: field.Type.Elem(),
+			Tag:  field.Tag,
+		}
+		ptr := reflect.New(elem.Type)
+		if err := f.setValue(elem, ptr.Elem(), input); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+	if isTextType(field.Type) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(input)); err != nil {
+			return ruleError{rule: "format", err: err}
+		}
+		return nil
+	}
+	if field.Type == durationType {
+		d, err := parseDuration(input)
+		if err != nil {
+			return ruleError{rule: "duration", err: err}
+		}
+		v.SetInt(d)
+		return nil
+	}
 	if IsIntType(field) {
+		bytes := field.Tag.Get(f.UnitField) == "bytes"
 		if IsUintType(field) {
-			value, err := strconv.ParseUint(input, 10, 64)
+			value, err := parseUint(input, field.Type.Bits(), bytes)
 			if err != nil {
-				return fmt.Errorf("%s必须为整数", title)
-			}
-			switch field.Type.Kind() {
-			case reflect.Uint:
-				if strconv.IntSize == 32 {
-					if value > math.MaxUint32 {
-						return fmt.Errorf("%s的数值越界", title)
-					}
-				}
-				v.Set(reflect.ValueOf(uint(value)))
-			case reflect.Uint8:
-				if value > math.MaxUint8 {
-					return fmt.Errorf("%s的数值越界", title)
-				}
-				v.Set(reflect.ValueOf(uint8(value)))
-			case reflect.Uint16:
-				if value > math.MaxUint16 {
-					return fmt.Errorf("%s的数值越界", title)
-				}
-				v.Set(reflect.ValueOf(uint16(value)))
-			case reflect.Uint32:
-				if value > math.MaxUint32 {
-					return fmt.Errorf("%s的数值越界", title)
-				}
-				v.Set(reflect.ValueOf(uint32(value)))
-			case reflect.Uint64:
-				v.Set(reflect.ValueOf(value))
+				return err
 			}
+			v.SetUint(value)
 		} else {
-			value, err := strconv.ParseInt(input, 10, 64)
+			value, err := parseInt(input, field.Type.Bits(), bytes)
 			if err != nil {
-				return fmt.Errorf("%s必须为整数", title)
-			}
-			switch field.Type.Kind() {
-			case reflect.Int:
-				if strconv.IntSize == 32 {
-					if value > math.MaxInt32 {
-						return fmt.Errorf("%s的数值越界", title)
-					}
-					if value < math.MinInt32 {
-						return fmt.Errorf("%s的数值越界", title)
-					}
-				}
-				v.Set(reflect.ValueOf(int(value)))
-			case reflect.Int8:
-				if value > math.MaxInt8 {
-					return fmt.Errorf("%s的数值越界", title)
-				}
-				if value < math.MinInt8 {
-					return fmt.Errorf("%s的数值越界", title)
-				}
-				v.Set(reflect.ValueOf(int8(value)))
-			case reflect.Int16:
-				if value > math.MaxInt16 {
-					return fmt.Errorf("%s的数值越界", title)
-				}
-				if value < math.MinInt16 {
-					return fmt.Errorf("%s的数值越界", title)
-				}
-				v.Set(reflect.ValueOf(int16(value)))
-			case reflect.Int32:
-				if value > math.MaxInt32 {
-					return fmt.Errorf("%s的数值越界", title)
-				}
-				if value < math.MinInt32 {
-					return fmt.Errorf("%s的数值越界", title)
-				}
-				v.Set(reflect.ValueOf(int32(value)))
-			case reflect.Int64:
-				v.Set(reflect.ValueOf(value))
+				return err
 			}
+			v.SetInt(value)
 		}
 	} else if IsFloatType(field) {
-		value, err := strconv.ParseFloat(input, 64)
+		value, err := parseFloat(input, field.Type.Bits())
 		if err != nil {
-			return fmt.Errorf("%s必须为浮点数", title)
-		}
-		switch field.Type.Kind() {
-		case reflect.Float32:
-			if value > math.MaxFloat32 {
-				return fmt.Errorf("%s的数值越界", title)
-			}
-			v.Set(reflect.ValueOf(float32(value)))
-		case reflect.Float64:
-			v.Set(reflect.ValueOf(value))
+			return err
 		}
+		v.SetFloat(value)
 	} else if IsStringType(field) {
 		v.SetString(input)
 	} else if field.Type.Kind() == reflect.Bool {
 		if input != "false" && input != "0" {
 			v.SetBool(true) //凡是有值的皆为真
 		}
-	} else if field.Type.String() == "time.Time" {
-		var t time.Time
-		//如果是纯数字则认为是时间戳
-		if n, err := strconv.ParseInt(input, 10, 64); err == nil {
-			t = time.Unix(n, 0)
-			v.Set(reflect.ValueOf(t))
-			return nil
-		}
-		var err error
-		if len(input) == 10 {
-			t, err = time.Parse(dateLayout, input)
-		} else if len(input) == 19 {
-			t, err = time.Parse(timeLayout, input)
-		} else {
-			err = fmt.Errorf("格式错误")
-		}
+	} else if field.Type == timeType {
+		t, err := f.parseTime(field, input)
 		if err != nil {
-			return err
+			return ruleError{rule: "time", err: err}
 		}
 		v.Set(reflect.ValueOf(t))
 	} else if field.Type.Kind() == reflect.Slice {
-		stringSlice := strings.Split(input, ",")
-		if len(stringSlice) == 0 {
-			return nil
+		elem := reflect.StructField{
+			Name: field.Name,
+			Type: field.Type.Elem(),
+			Tag:  field.Tag,
 		}
-		switch field.Type.String() {
-		case "[]string":
-			slice := reflect.MakeSlice(reflect.TypeOf([]string{}), len(stringSlice), len(stringSlice))
-			for i := 0; i < len(stringSlice); i++ {
-				slice.Index(i).Set(reflect.ValueOf(stringSlice[i]))
-			}
-			v.Set(slice)
-		case "[]int", "[]uint", "[]int8", "[]uint8", "[]int16", "[]uint16", "[]int32", "[]uint32", "[]int64", "[]uint64":
-			var slice reflect.Value
-			switch field.Type.String() {
-			case "[]int":
-				slice = reflect.MakeSlice(reflect.TypeOf([]int{}), len(stringSlice), len(stringSlice))
-			case "[]uint":
-				slice = reflect.MakeSlice(reflect.TypeOf([]uint{}), len(stringSlice), len(stringSlice))
-			case "[]int8":
-				slice = reflect.MakeSlice(reflect.TypeOf([]int8{}), len(stringSlice), len(stringSlice))
-			case "[]uint8":
-				slice = reflect.MakeSlice(reflect.TypeOf([]uint8{}), len(stringSlice), len(stringSlice))
-			case "[]int16":
-				slice = reflect.MakeSlice(reflect.TypeOf([]int16{}), len(stringSlice), len(stringSlice))
-			case "[]uint16":
-				slice = reflect.MakeSlice(reflect.TypeOf([]uint16{}), len(stringSlice), len(stringSlice))
-			case "[]int32":
-				slice = reflect.MakeSlice(reflect.TypeOf([]int32{}), len(stringSlice), len(stringSlice))
-			case "[]uint32":
-				slice = reflect.MakeSlice(reflect.TypeOf([]uint32{}), len(stringSlice), len(stringSlice))
-			case "[]int64":
-				slice = reflect.MakeSlice(reflect.TypeOf([]int64{}), len(stringSlice), len(stringSlice))
-			case "[]uint64":
-				slice = reflect.MakeSlice(reflect.TypeOf([]uint64{}), len(stringSlice), len(stringSlice))
-			}
-			for i := 0; i < len(stringSlice); i++ {
-				n, err := strconv.ParseInt(stringSlice[i], 10, 64)
-				if err != nil {
-					return err
-				}
-
-				switch field.Type.String() {
-				case "[]int":
-					slice.Index(i).Set(reflect.ValueOf(int(n)))
-				case "[]uint":
-					slice.Index(i).Set(reflect.ValueOf(uint(n)))
-				case "[]int8":
-					slice.Index(i).Set(reflect.ValueOf(int8(n)))
-				case "[]uint8":
-					slice.Index(i).Set(reflect.ValueOf(uint8(n)))
-				case "[]int16":
-					slice.Index(i).Set(reflect.ValueOf(int16(n)))
-				case "[]uint16":
-					slice.Index(i).Set(reflect.ValueOf(uint16(n)))
-				case "[]int32":
-					slice.Index(i).Set(reflect.ValueOf(int32(n)))
-				case "[]uint32":
-					slice.Index(i).Set(reflect.ValueOf(uint32(n)))
-				case "[]int64":
-					slice.Index(i).Set(reflect.ValueOf(n))
-				case "[]uint64":
-					slice.Index(i).Set(reflect.ValueOf(uint64(n)))
-				}
-			}
-			v.Set(slice)
-		case "[]float32", "[]float64":
-			var slice reflect.Value
-			if field.Type.String() == "[]float64" {
-				slice = reflect.MakeSlice(reflect.TypeOf([]float64{}), len(stringSlice), len(stringSlice))
-			} else {
-				slice = reflect.MakeSlice(reflect.TypeOf([]float32{}), len(stringSlice), len(stringSlice))
-			}
-			for i := 0; i < len(stringSlice); i++ {
-				n, err := strconv.ParseFloat(stringSlice[i], 64)
-				if err != nil {
-					return err
-				}
-				if field.Type.String() == "[]float64" {
-					slice.Index(i).Set(reflect.ValueOf(n))
-				} else {
-					slice.Index(i).Set(reflect.ValueOf(float32(n)))
-				}
-			}
-			v.Set(slice)
+		item := reflect.New(elem.Type).Elem()
+		if err := f.setValue(elem, item, input); err != nil {
+			return err
 		}
+		v.Set(reflect.Append(v, item))
 	}
 	return nil
 }
 
+//parseInt 将input转换为bits位的有符号整数，bytes为true时input可以是带单位的大小。转换失败时返回ruleError
+func parseInt(input string, bits int, bytes bool) (int64, error) {
+	if bytes {
+		n, err := parseSize(input)
+		if err != nil {
+			return 0, ruleError{rule: "size", err: err}
+		}
+		input = strconv.FormatInt(n, 10)
+	}
+	value, err := strconv.ParseInt(input, 10, 64)
+	if err != nil {
+		return 0, ruleError{rule: "integer"}
+	}
+	if bits < 64 && (value < -1<<uint(bits-1) || value > 1<<uint(bits-1)-1) {
+		return 0, ruleError{rule: "overflow"}
+	}
+	return value, nil
+}
+
+//parseUint 将input转换为bits位的无符号整数，bytes为true时input可以是带单位的大小。转换失败时返回ruleError
+func parseUint(input string, bits int, bytes bool) (uint64, error) {
+	if bytes {
+		n, err := parseSize(input)
+		if err != nil {
+			return 0, ruleError{rule: "size", err: err}
+		}
+		input = strconv.FormatInt(n, 10)
+	}
+	value, err := strconv.ParseUint(input, 10, 64)
+	if err != nil {
+		return 0, ruleError{rule: "integer"}
+	}
+	if bits < 64 && value > 1<<uint(bits)-1 {
+		return 0, ruleError{rule: "overflow"}
+	}
+	return value, nil
+}
+
+//parseFloat 将input转换为bits位的浮点数，转换失败时返回ruleError
+func parseFloat(input string, bits int) (float64, error) {
+	value, err := strconv.ParseFloat(input, 64)
+	if err != nil {
+		return 0, ruleError{rule: "float"}
+	}
+	if bits == 32 && value > math.MaxFloat32 {
+		return 0, ruleError{rule: "overflow"}
+	}
+	return value, nil
+}
+
+var timeUnits = map[string]time.Duration{
+	"s":  time.Second,
+	"ms": time.Millisecond,
+	"us": time.Microsecond,
+	"ns": time.Nanosecond,
+}
+
+//parseTime 按字段的TimeFormatField和TimeUnitField解析时间
+func (f *Form) parseTime(field reflect.StructField, input string) (time.Time, error) {
+	return f.parseTimeAs(input, field.Tag.Get(f.TimeFormatField), field.Tag.Get(f.TimeUnitField))
+}
+
+//parseTimeAs 解析时间。format不为空时只使用该格式；unit不为空时只接受该单位的时间戳；
+//都为空时纯数字视为秒级时间戳，否则依次尝试TimeLayouts
+func (f *Form) parseTimeAs(input, format, unit string) (time.Time, error) {
+	loc := f.Location
+	if format != "" {
+		if loc == nil {
+			loc = time.UTC
+		}
+		return time.ParseInLocation(format, input, loc)
+	}
+	n, err := strconv.ParseInt(input, 10, 64)
+	if unit != "" || err == nil {
+		if err != nil {
+			return time.Time{}, err
+		}
+		d, ok := timeUnits[unit]
+		if unit == "" {
+			d, ok = time.Second, true
+		}
+		if !ok {
+			return time.Time{}, fmt.Errorf("不支持的时间单位%s", unit)
+		}
+		t := time.Unix(n/int64(time.Second/d), n%int64(time.Second/d)*int64(d))
+		if loc != nil {
+			t = t.In(loc)
+		}
+		return t, nil
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	for _, layout := range f.TimeLayouts {
+		if t, err := time.ParseInLocation(layout, input, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("时间格式错误:%s", input)
+}
+
+//joinKey 将嵌套struct中字段的key加上前缀
+func (f *Form) joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	if f.KeyStyle == BracketKey {
+		return prefix + "[" + key + "]"
+	}
+	return prefix + "." + key
+}
+
+//indexKey slice中第i个元素的前缀
+func (f *Form) indexKey(prefix string, i int) string {
+	return prefix + "[" + strconv.Itoa(i) + "]"
+}
+
+//defaultField 返回fields中第一个有值的tag的名称，忽略名称之后的选项，没有名称时返回字段名
 func defaultField(t reflect.StructField, fields []string) string {
-	var field string
+	name, _ := lookupTag(t, fields)
+	if name == "" {
+		return t.Name
+	}
+	return name
+}
+
+//lookupTag 返回fields中第一个有值的tag的名称和选项，值为-的tag视为没有设置
+func lookupTag(t reflect.StructField, fields []string) (string, tagOptions) {
 	for _, f := range fields {
-		field = t.Tag.Get(f)
-		if field != "" {
-			break
+		if tag := t.Tag.Get(f); tag != "" && tag != "-" {
+			return parseTag(tag)
 		}
 	}
-	if field == "" {
-		field = t.Name
+	return "", ""
+}
+
+//tagOptions tag中名称之后以,分隔的选项，如json:"name,omitempty"中的omitempty
+type tagOptions string
+
+//parseTag 将tag拆分为名称和选项
+func parseTag(tag string) (string, tagOptions) {
+	if i := strings.Index(tag, ","); i != -1 {
+		return tag[:i], tagOptions(tag[i+1:])
 	}
-	return field
+	return tag, ""
+}
+
+//Contains 是否包含选项name
+func (o tagOptions) Contains(name string) bool {
+	for _, opt := range strings.Split(string(o), ",") {
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+//skipField 字段的form key为-时跳过，与encoding/json一样-,表示key为-
+func (f *Form) skipField(t reflect.StructField) bool {
+	for _, name := range f.FormFields {
+		if tag := t.Tag.Get(name); tag != "" {
+			return tag == "-"
+		}
+	}
+	return false
+}
+
+//quoted 字段是否设置了string选项，设置后输入可以是带引号的字符串，如"42"
+func (f *Form) quoted(t reflect.StructField) bool {
+	_, opts := lookupTag(t, f.FormFields)
+	return opts.Contains("string")
 }
 
 type rule struct {
 	Name   string
 	Params []string
+	//limits min、max、range按字段类型解析后的参数，与Params一一对应，其它规则为nil
+	limits []limit
 }
 
 func (f *Form) parseRules(t reflect.StructField) []rule {
@@ -1,13 +1,20 @@
 package form
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"math"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -24,12 +31,35 @@ func makeContext(data url.Values) echo.Context {
 	return ctx
 }
 
+func makeMultipartContext(data url.Values, files map[string][]string) echo.Context {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for k, vs := range data {
+		for _, v := range vs {
+			w.WriteField(k, v)
+		}
+	}
+	//files的value依次为文件名和内容
+	for k, fs := range files {
+		for i := 0; i+1 < len(fs); i += 2 {
+			fw, _ := w.CreateFormFile(k, fs[i])
+			fw.Write([]byte(fs[i+1]))
+		}
+	}
+	w.Close()
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+	return echo.New().NewContext(req, httptest.NewRecorder())
+}
+
 func goodData() url.Values {
 	data := url.Values{}
 	data.Set("username", "jiazhoulvke")
 	data.Set("age", "24")
 	data.Set("weight", "60")
 	data.Set("Number", "24")
+	//foo.Birthday是required的，time.Time字段的规则以前不会执行，现在会检查
+	data.Set("Birthday", "2007-12-13")
 	return data
 }
 
@@ -140,6 +170,18 @@ func TestCheck(t *testing.T) {
 		So(err, ShouldBeNil)
 	})
 
+	Convey("测试time.Time字段的规则", t, func() {
+		data := goodData()
+		data.Del("Birthday")
+		err := Check(makeContext(data), &f)
+		So(err, ShouldNotBeNil)
+		So(err.(*FieldError).Field, ShouldEqual, "Birthday")
+		So(err.(*FieldError).Rule, ShouldEqual, "required")
+
+		data.Set("Birthday", "2007-12-13")
+		So(Check(makeContext(data), &f), ShouldBeNil)
+	})
+
 	Convey("测试错误struct tag", t, func() {
 		var err error
 		var testRange = struct {
@@ -199,6 +241,78 @@ func TestCheck(t *testing.T) {
 
 	})
 
+	Convey("测试启动时检查struct tag", t, func() {
+		type address struct {
+			City string `form:"city" valid:"required;max:x"`
+		}
+		type item struct {
+			Qty int `form:"qty" valid:"min:1" default:"one"`
+		}
+		type order struct {
+			Name     string                `valid:"requird"`
+			Age      int                   `valid:"range:5"`
+			Paid     bool                  `valid:"max:1"`
+			Quota    int64                 `unit:"bytes" valid:"max:10MB" default:"1XB"`
+			Timeout  time.Duration         `valid:"max:1h" default:"1x"`
+			Confirm  string                `valid:"eqfield:Pasword"`
+			Invoice  string                `valid:"required_if:Paid"`
+			Avatar   *multipart.FileHeader `valid:"filesize:2XB"`
+			Level    *int8                 `valid:"range:1,10" default:"300"`
+			Tags     []int                 `valid:"max:3" default:"1,a"`
+			Email    string                `valid:"email:1;"`
+			Shipping address               `form:"shipping"`
+			Items    []item                `form:"items" valid:"max:a"`
+		}
+		err := Validate(order{})
+		So(err, ShouldNotBeNil)
+		errs, ok := err.(TagErrors)
+		So(ok, ShouldBeTrue)
+		fields := make([]string, 0, len(errs))
+		for _, e := range errs {
+			fields = append(fields, e.Field+"/"+e.Tag)
+		}
+		So(fields, ShouldResemble, []string{
+			"Name/valid", "Age/valid", "Paid/valid", "Quota/default", "Timeout/default",
+			"Confirm/valid", "Invoice/valid", "Avatar/valid", "Level/default", "Tags/default",
+			"Email/valid", "Shipping.City/valid", "Items.Qty/default", "Items/valid",
+		})
+		So(errs[0].Error(), ShouldEqual, "form.order.Name的valid tag错误:requird:检测器requird找不到")
+		So(errs[1].Err.Error(), ShouldEqual, "range:需要2个参数")
+
+		type negative struct {
+			Quota int64 `unit:"bytes" valid:"max:-1MB"`
+		}
+		err = Validate(negative{})
+		So(err, ShouldNotBeNil)
+		So(err.(TagErrors)[0].Err.Error(), ShouldEqual, `max:参数"-1MB"错误:大小不能为负数:-1MB`)
+
+		type good struct {
+			Name     string    `valid:"required;range:4,16" default:"guest"`
+			Age      *int      `valid:"range:18,120" default:"18"`
+			Created  time.Time `default:"2020-01-02"`
+			Confirm  string    `valid:"eqfield:Name;required_with:Name,Age"`
+			Shipping address   `form:"shipping"`
+			Tenant   string    `valid:"tenant:any,params"`
+		}
+		type fixed struct {
+			City string `form:"city" valid:"required;max:32"`
+		}
+		So(Validate(&good{Shipping: address{}}), ShouldNotBeNil)
+		f := New()
+		f.RegisterCheck("max", func(c Context) error { return nil })
+		f.RegisterCheck("tenant", func(c Context) error { return nil })
+		So(f.Validate(reflect.TypeOf(good{})), ShouldBeNil) //覆盖了max后不再检查参数
+		So(Validate(fixed{}, &fixed{}), ShouldBeNil)
+		So(Validate(1).Error(), ShouldEqual, "int不是struct")
+		//不是struct的参数不影响其它参数的检查
+		count := len(Validate(order{}).(TagErrors))
+		tagErrs := Validate(order{}, 3).(TagErrors)
+		So(tagErrs, ShouldHaveLength, count+1)
+		So(tagErrs[count].Error(), ShouldEqual, "int不是struct")
+		So(func() { MustRegister(fixed{}) }, ShouldNotPanic)
+		So(func() { MustRegister(order{}) }, ShouldPanic)
+	})
+
 	Convey("测试空值", t, func() {
 		var err error
 		var foo = struct {
@@ -225,6 +339,279 @@ func TestCheck(t *testing.T) {
 		}
 	})
 
+	Convey("测试CheckAll", t, func() {
+		data := goodData()
+		data.Set("username", "_abc")
+		data.Set("age", "abc")
+		data.Set("Email", "foo@")
+		ctx = makeContext(data)
+		err := CheckAll(ctx, &f)
+		So(err, ShouldNotBeNil)
+		errs, ok := err.(ValidationErrors)
+		So(ok, ShouldBeTrue)
+		fields := make([]string, 0, len(errs))
+		for _, e := range errs {
+			fields = append(fields, e.Field+":"+e.Rule)
+		}
+		So(fields, ShouldResemble, []string{
+			"UserName:range",
+			"UserName:username",
+			"Age:integer",
+			"Age:range",
+			"Email:email",
+		})
+
+		ctx = makeContext(goodData())
+		err = CheckAll(ctx, &f)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("测试FieldError", t, func() {
+		data := goodData()
+		data.Set("age", "17")
+		ctx = makeContext(data)
+		err := Check(ctx, &f)
+		fe, ok := err.(*FieldError)
+		So(ok, ShouldBeTrue)
+		So(fe, ShouldResemble, &FieldError{
+			Field:   "Age",
+			Key:     "age",
+			Title:   "年龄",
+			Rule:    "range",
+			Params:  []string{"18", "60"},
+			Value:   "17",
+			Message: "年龄不能小于18",
+		})
+	})
+
+	Convey("测试多语言", t, func() {
+		data := goodData()
+		data.Set("age", "17")
+		ctx = makeContext(data)
+		err := Check(ctx, &f)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "年龄不能小于18")
+
+		ctx.Request().Header = http.Header{}
+		ctx.Request().Header.Set("Accept-Language", "fr-FR, en-US;q=0.8, zh-CN;q=0.5")
+		err = Check(ctx, &f)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "年龄 must not be less than 18")
+
+		ctx.Request().Header.Set("Accept-Language", "zh, en;q=0.9")
+		err = Check(ctx, &f)
+		So(err.Error(), ShouldEqual, "年龄不能小于18")
+
+		ef := New(func(f *Form) {
+			f.Lang = "en"
+		})
+		ctx = makeContext(data)
+		err = ef.Check(&f, ctx)
+		So(err.Error(), ShouldEqual, "年龄 must not be less than 18")
+
+		ef.Translator = Catalog{
+			"en": Messages{"min": "%[1]s is too small"},
+		}
+		err = ef.Check(&f, ctx)
+		So(err.Error(), ShouldEqual, "年龄 is too small")
+
+		c := Catalog{
+			"zh-TW": Messages{"min": "tw"},
+			"zh-CN": Messages{"min": "cn"},
+			"zh-HK": Messages{"min": "hk"},
+		}
+		for i := 0; i < 20; i++ {
+			msg, ok := c.Translate("zh", "min")
+			So(ok, ShouldBeTrue)
+			So(msg, ShouldEqual, "cn") //同一主语言有多个地区时按语言标签排序取第一个
+		}
+		msg, _ := c.Translate("zh-tw", "min")
+		So(msg, ShouldEqual, "tw")
+		So(testing.AllocsPerRun(100, func() { c.lookup("zh") }), ShouldEqual, 0) //排好序的语言标签已缓存
+
+		delete(c, "zh-CN")
+		c["zh-AB"] = Messages{"min": "ab"}
+		msg, _ = c.Translate("zh", "min")
+		So(msg, ShouldEqual, "ab") //修改Catalog后重新排序
+	})
+
+	Convey("测试自定义错误信息", t, func() {
+		var u = struct {
+			UserName string `form:"username" valid:"required;min:8" msg:"required=请输入用户名;min=用户名太短"`
+			Age      int    `form:"age" valid:"integer" msg:"integer=年龄只能填数字"`
+		}{}
+		ctx = makeContext(url.Values{})
+		err := Check(ctx, &u)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "请输入用户名")
+
+		ctx = makeContext(url.Values{
+			"username": []string{"abc"},
+			"age":      []string{"abc"},
+		})
+		err = CheckAll(ctx, &u)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "用户名太短;年龄只能填数字")
+
+		err = Bind(ctx, &u)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "年龄只能填数字")
+	})
+
+	Convey("测试校验struct中的值", t, func() {
+		vf := New(func(f *Form) {
+			f.CheckMode = CheckValue
+		})
+		type item struct {
+			Name     string    `title:"名称" valid:"required;range:2,8"`
+			Count    int       `title:"数量" valid:"required;range:1,10"`
+			Price    float64   `title:"价格" valid:"max:99.5"`
+			Tags     []string  `title:"标签" valid:"range:1,3"`
+			Released time.Time `title:"上架时间" valid:"required"`
+		}
+		good := item{
+			Name:     "apple",
+			Count:    3,
+			Price:    9.9,
+			Tags:     []string{"fruit"},
+			Released: time.Now(),
+		}
+		So(vf.Check(&good, nil), ShouldBeNil)
+
+		bad := good
+		bad.Count = 11
+		err := vf.Check(&bad, nil)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "数量不能大于10")
+		So(err.(*FieldError).Value, ShouldEqual, "11")
+
+		bad = good
+		bad.Price = 100
+		So(vf.Check(bad, nil), ShouldNotBeNil)
+
+		bad = good
+		bad.Tags = []string{"a", "b", "c", "d"}
+		err = vf.Check(&bad, nil)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "标签不能超过3项")
+
+		err = vf.CheckAll(&item{}, nil)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "名称不能为空;数量不能为空;数量不能小于1;上架时间不能为空")
+
+		//零值按数值校验，与提交count=0&price=0的结果一致
+		type zero struct {
+			Count int     `form:"count" title:"数量" valid:"min:1"`
+			Price float64 `form:"price" title:"价格" valid:"range:0.01,100"`
+			Stock *int    `form:"stock" title:"库存" valid:"min:1"`
+		}
+		err = vf.CheckAllSource(&zero{}, nil)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "数量不能小于1;价格不能小于0.010000")
+		So(err.(ValidationErrors)[0].Value, ShouldEqual, "0")
+		rerr := CheckAllSource(ValuesSource(url.Values{"count": []string{"0"}, "price": []string{"0"}}), &zero{})
+		So(rerr, ShouldResemble, err)
+	})
+
+	Convey("测试字段比较", t, func() {
+		type register struct {
+			Password  string    `form:"password" title:"密码"`
+			Password2 string    `form:"password2" title:"确认密码" valid:"eqfield:Password"`
+			OldPass   string    `form:"old_password" title:"旧密码" valid:"nefield:Password"`
+			Start     time.Time `form:"start" title:"开始时间"`
+			End       time.Time `form:"end" title:"结束时间" valid:"gtfield:Start"`
+			Min       int       `form:"min" title:"最小值"`
+			Max       int       `form:"max" title:"最大值" valid:"gtefield:Min"`
+			Typo      string    `form:"typo" valid:"eqfield:Nothing"`
+		}
+		data := url.Values{
+			"password":     []string{"secret"},
+			"password2":    []string{"secret"},
+			"old_password": []string{"old"},
+			"start":        []string{"2020-01-02"},
+			"end":          []string{"2020-01-03 00:00:00"},
+			"min":          []string{"9"},
+			"max":          []string{"10"},
+		}
+		var r register
+		ctx = makeContext(data)
+		So(Check(ctx, &r), ShouldBeNil)
+
+		bad := func(key, value string) error {
+			d := url.Values{}
+			for k, v := range data {
+				d[k] = v
+			}
+			d.Set(key, value)
+			return Check(makeContext(d), &r)
+		}
+		So(bad("password2", "secreT").Error(), ShouldEqual, "确认密码必须与密码相同")
+		So(bad("old_password", "secret").Error(), ShouldEqual, "旧密码不能与密码相同")
+		So(bad("end", "2020-01-02").Error(), ShouldEqual, "结束时间必须大于开始时间")
+		So(bad("max", "8").Error(), ShouldEqual, "最大值不能小于最小值")
+		So(bad("max", "9"), ShouldBeNil)
+		So(bad("typo", "a"), ShouldNotBeNil)
+
+		vf := New(func(f *Form) {
+			f.CheckMode = CheckValue
+		})
+		So(Bind(ctx, &r), ShouldBeNil)
+		So(vf.Check(&r, nil), ShouldBeNil)
+		r.End = r.Start.Add(-time.Second)
+		So(vf.Check(&r, nil).Error(), ShouldEqual, "结束时间必须大于开始时间")
+	})
+
+	Convey("测试条件必填", t, func() {
+		type order struct {
+			NeedInvoice  int    `form:"need_invoice"`
+			InvoiceTitle string `form:"invoice_title" title:"发票抬头" valid:"required_if:NeedInvoice,1"`
+			Email        string `form:"email" title:"邮箱" valid:"required_without:Mobile"`
+			Mobile       string `form:"mobile" title:"手机号码" valid:"required_without:Email"`
+			Remark       string `form:"remark" title:"备注" valid:"required_with:Email,Mobile"`
+			Coupon       string `form:"coupon" title:"优惠码" valid:"required_unless:channel,web,app"`
+		}
+		var o order
+		check := func(data url.Values) error {
+			return CheckAll(makeContext(data), &o)
+		}
+		err := check(url.Values{
+			"need_invoice": []string{"1"},
+			"channel":      []string{"shop"},
+		})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "发票抬头不能为空;邮箱不能为空;手机号码不能为空;优惠码不能为空")
+
+		err = check(url.Values{
+			"need_invoice": []string{"0"},
+			"mobile":       []string{"13812345678"},
+			"channel":      []string{"web"},
+		})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "备注不能为空")
+
+		err = check(url.Values{
+			"mobile":  []string{"13812345678"},
+			"remark":  []string{"hello"},
+			"channel": []string{"app"},
+		})
+		So(err, ShouldBeNil)
+
+		vf := New(func(f *Form) {
+			f.CheckMode = CheckValue
+		})
+		err = vf.CheckAll(&order{NeedInvoice: 1, Email: "foo@bar.com", Remark: "hi"}, nil)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "发票抬头不能为空;优惠码不能为空")
+
+		type refund struct {
+			NeedInvoice  int    `form:"need_invoice"`
+			InvoiceTitle string `valid:"required_unless:NeedInvoice,0"`
+		}
+		So(vf.Check(&refund{}, nil), ShouldBeNil) //引用的数值字段为0时与输入"0"一致
+		So(CheckAll(makeContext(url.Values{"need_invoice": []string{"0"}}), &refund{}), ShouldBeNil)
+		So(vf.Check(&refund{NeedInvoice: 1}, nil).Error(), ShouldEqual, "InvoiceTitle不能为空")
+	})
+
 	Convey("测试手机号码", t, func() {
 		mobiles := []string{"13412345678", "19912345678", "17512345678", "13812345678"}
 		for _, mobile := range mobiles {
@@ -271,6 +658,83 @@ func TestCheck(t *testing.T) {
 		err = Check(ctx, &f)
 		So(err, ShouldNotBeNil) //值不正确，应该报错
 	})
+
+	Convey("测试Form的检测器", t, func() {
+		var o = struct {
+			Foo string `valid:"even"`
+			Bar string `valid:"hello"`
+		}{}
+		src := ValuesSource(url.Values{"Foo": []string{"3"}, "Bar": []string{"world"}})
+		f := New()
+		So(f.CheckSource(&o, src), ShouldNotBeNil) //检测器even不存在，应该报错
+
+		f.RegisterCheck("even", func(c Context) error {
+			if n, _ := strconv.Atoi(c.Input); n%2 != 0 {
+				return fmt.Errorf("%s必须是偶数", c.Title)
+			}
+			return nil
+		})
+		err := f.CheckSource(&o, src)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "Foo必须是偶数")
+		So(f.CheckSource(&o, ValuesSource(url.Values{"Foo": []string{"4"}, "Bar": []string{"world"}})), ShouldBeNil) //全局的hello仍然可用
+		So(New().CheckSource(&o, src), ShouldNotBeNil)                                                               //其它Form没有even
+
+		f.RegisterCheck("hello", func(c Context) error {
+			return nil
+		})
+		So(f.CheckSource(&o, ValuesSource(url.Values{"Foo": []string{"4"}})), ShouldBeNil) //覆盖了全局的hello
+		So(CheckSource(ValuesSource(url.Values{"Bar": []string{"x"}}), &o), ShouldNotBeNil)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(2)
+			go func(i int) {
+				defer wg.Done()
+				name := fmt.Sprintf("concurrent%d", i)
+				f.RegisterCheck(name, Required)
+				AddCheckFunc(name, Required)
+			}(i)
+			go func() {
+				defer wg.Done()
+				f.CheckSource(&o, src)
+			}()
+		}
+		wg.Wait()
+		_, ok := f.checkFunc("concurrent7")
+		So(ok, ShouldBeTrue)
+	})
+}
+
+type money struct {
+	Cents int64
+}
+
+func (m *money) UnmarshalText(text []byte) error {
+	f, err := strconv.ParseFloat(string(text), 64)
+	if err != nil {
+		return err
+	}
+	m.Cents = int64(math.Round(f * 100))
+	return nil
+}
+
+func (m money) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d.%02d", m.Cents/100, m.Cents%100)), nil
+}
+
+type status int
+
+func (s *status) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "active":
+		*s = 1
+	case "disabled":
+		*s = 2
+	default:
+		return fmt.Errorf("unknown status %q", text)
+	}
+	return nil
 }
 
 func TestBind(t *testing.T) {
@@ -358,6 +822,22 @@ func TestBind(t *testing.T) {
 			So(err, ShouldNotBeNil)
 		})
 
+		Convey("测试FieldError", func() {
+			ctx := makeContext(url.Values{
+				"n": []string{"256"},
+			})
+			err := Bind(ctx, &struct {
+				N uint8 `form:"n" title:"数量"`
+			}{})
+			fe, ok := err.(*FieldError)
+			So(ok, ShouldBeTrue)
+			So(fe.Field, ShouldEqual, "N")
+			So(fe.Key, ShouldEqual, "n")
+			So(fe.Rule, ShouldEqual, "overflow")
+			So(fe.Value, ShouldEqual, "256")
+			So(fe.Message, ShouldEqual, "数量的数值越界")
+		})
+
 		Convey("测试无符号整数", func() {
 			ctx := makeContext(url.Values{
 				"N": []string{fmt.Sprintf("%v", uint64(math.MaxUint64))},
@@ -498,21 +978,115 @@ func TestBind(t *testing.T) {
 			So(isEqual, ShouldBeTrue)
 		})
 
+		Convey("测试嵌套struct的key", func() {
+			type (
+				address struct {
+					City   string `form:"city" title:"城市" valid:"required"`
+					Street string `form:"street"`
+				}
+				base struct {
+					Remark string `form:"remark"`
+				}
+				order struct {
+					base
+					Shipping address `form:"shipping"`
+					Billing  address `form:"billing"`
+				}
+			)
+			data := url.Values{
+				"remark":          []string{"hi"},
+				"shipping.city":   []string{"Shenzhen"},
+				"shipping.street": []string{"Shennan Rd"},
+				"billing.city":    []string{"Beijing"},
+			}
+			var o order
+			ctx := makeContext(data)
+			So(Bind(ctx, &o), ShouldBeNil)
+			So(o.Remark, ShouldEqual, "hi")
+			So(o.Shipping, ShouldResemble, address{City: "Shenzhen", Street: "Shennan Rd"})
+			So(o.Billing, ShouldResemble, address{City: "Beijing"})
+			So(Check(ctx, &o), ShouldBeNil)
+
+			data.Del("billing.city")
+			err := Check(makeContext(data), &o)
+			So(err, ShouldNotBeNil)
+			So(err.(*FieldError).Key, ShouldEqual, "billing.city")
+
+			bf := New(func(f *Form) {
+				f.KeyStyle = BracketKey
+			})
+			var o2 order
+			ctx = makeContext(url.Values{
+				"shipping[city]": []string{"Shenzhen"},
+				"billing[city]":  []string{"Beijing"},
+			})
+			So(bf.Bind(&o2, ctx), ShouldBeNil)
+			So(o2.Shipping.City, ShouldEqual, "Shenzhen")
+			So(o2.Billing.City, ShouldEqual, "Beijing")
+			So(bf.Check(&o2, ctx), ShouldBeNil)
+		})
+
+		Convey("测试struct slice", func() {
+			type (
+				item struct {
+					Sku string `form:"sku" title:"商品编号" valid:"required"`
+					Qty int    `form:"qty" title:"数量" valid:"range:1,99"`
+				}
+				order struct {
+					Items []item `form:"items" title:"商品" valid:"required;max:3"`
+				}
+			)
+			data := url.Values{
+				"items[0].sku": []string{"A"},
+				"items[0].qty": []string{"2"},
+				"items[1].sku": []string{"B"},
+				"items[1].qty": []string{"1"},
+			}
+			var o order
+			ctx := makeContext(data)
+			So(Bind(ctx, &o), ShouldBeNil)
+			So(o.Items, ShouldResemble, []item{{"A", 2}, {"B", 1}})
+			So(Check(ctx, &o), ShouldBeNil)
+
+			data.Set("items[1].qty", "100")
+			data.Set("items[2].qty", "1")
+			err := CheckAll(makeContext(data), &o)
+			So(err, ShouldNotBeNil)
+			errs := err.(ValidationErrors)
+			So(len(errs), ShouldEqual, 2)
+			So(errs[0].Key, ShouldEqual, "items[1].qty")
+			So(errs[1].Key, ShouldEqual, "items[2].sku")
+
+			err = Check(makeContext(url.Values{}), &order{})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "商品不能为空")
+
+			vf := New(func(f *Form) {
+				f.CheckMode = CheckValue
+			})
+			err = vf.Check(&order{Items: []item{{"A", 1}, {"", 1}}}, nil)
+			So(err, ShouldNotBeNil)
+			So(err.(*FieldError).Key, ShouldEqual, "items[1].sku")
+			err = vf.Check(&order{Items: []item{{"A", 1}, {"B", 1}, {"C", 1}, {"D", 1}}}, nil)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "商品不能超过3项")
+		})
+
 		Convey("测试slice", func() {
 			type foo struct {
-				StringSlice  []string  `json:"string_slice"`
-				IntSlice     []int     `json:"int_slice"`
-				UIntSlice    []uint    `json:"uint_slice"`
-				Int8Slice    []int8    `json:"int8_slice"`
-				UInt8Slice   []uint8   `json:"uint8_slice"`
-				Int16Slice   []int16   `json:"int16_slice"`
-				UInt16Slice  []uint16  `json:"uint16_slice"`
-				Int32Slice   []int32   `json:"int32_slice"`
-				UInt32Slice  []uint32  `json:"uint32_slice"`
-				Int64Slice   []int64   `json:"int64_slice"`
-				UInt64Slice  []uint64  `json:"uint64_slice"`
-				Float32Slice []float32 `json:"float32_slice"`
-				Float64Slice []float64 `json:"float64_slice"`
+				StringSlice  []string  `json:"string_slice" sep:","`
+				IntSlice     []int     `json:"int_slice" sep:","`
+				UIntSlice    []uint    `json:"uint_slice" sep:","`
+				Int8Slice    []int8    `json:"int8_slice" sep:","`
+				UInt8Slice   []uint8   `json:"uint8_slice" sep:","`
+				Int16Slice   []int16   `json:"int16_slice" sep:","`
+				UInt16Slice  []uint16  `json:"uint16_slice" sep:","`
+				Int32Slice   []int32   `json:"int32_slice" sep:","`
+				UInt32Slice  []uint32  `json:"uint32_slice" sep:","`
+				Int64Slice   []int64   `json:"int64_slice" sep:","`
+				UInt64Slice  []uint64  `json:"uint64_slice" sep:","`
+				Float32Slice []float32 `json:"float32_slice" sep:","`
+				Float64Slice []float64 `json:"float64_slice" sep:","`
 			}
 			data := url.Values{
 				"string_slice":  []string{"3,5,7"},
@@ -548,6 +1122,278 @@ func TestBind(t *testing.T) {
 			So(f.Float64Slice, ShouldResemble, []float64{-1.3, 1.5, 1.7})
 		})
 
+		Convey("测试重复的key", func() {
+			type foo struct {
+				Tags   []string `form:"tag" title:"标签" valid:"required;max:3"`
+				IDs    []int    `form:"id"`
+				Names  []string `form:"names" sep:"|"`
+				Levels []int    `form:"level" default:"1,2"`
+			}
+			data := url.Values{
+				"tag":   []string{"a,b", "c"},
+				"id":    []string{"3", "5"},
+				"names": []string{"foo|bar", "baz"},
+			}
+			var f foo
+			ctx := makeContext(data)
+			So(Bind(ctx, &f), ShouldBeNil)
+			So(f.Tags, ShouldResemble, []string{"a,b", "c"})
+			So(f.IDs, ShouldResemble, []int{3, 5})
+			So(f.Names, ShouldResemble, []string{"foo", "bar", "baz"})
+			So(f.Levels, ShouldResemble, []int{1, 2})
+			So(Check(ctx, &f), ShouldBeNil)
+
+			data.Add("tag", "d")
+			data.Add("tag", "e")
+			err := Check(makeContext(data), &f)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "标签不能超过3项")
+
+			data.Add("id", "x")
+			err = Bind(makeContext(data), &f)
+			So(err, ShouldNotBeNil)
+			So(err.(*FieldError).Value, ShouldEqual, "x")
+		})
+
+		Convey("测试指针", func() {
+			type (
+				address struct {
+					City string `form:"city" title:"城市" valid:"required"`
+				}
+				profile struct {
+					Age      *int       `form:"age" title:"年龄" valid:"required;min:0"`
+					Nickname *string    `form:"nickname"`
+					Public   *bool      `form:"public"`
+					Birthday *time.Time `form:"birthday"`
+					Level    *int       `form:"level" default:"3"`
+					Address  *address   `form:"address"`
+				}
+			)
+			var p profile
+			ctx := makeContext(url.Values{
+				"age":    []string{"0"},
+				"public": []string{"false"},
+			})
+			So(Bind(ctx, &p), ShouldBeNil)
+			So(p.Age, ShouldNotBeNil)
+			So(*p.Age, ShouldEqual, 0)
+			So(p.Nickname, ShouldBeNil)
+			So(*p.Public, ShouldBeFalse)
+			So(p.Birthday, ShouldBeNil)
+			So(*p.Level, ShouldEqual, 3)
+			So(p.Address, ShouldBeNil)
+			So(Check(ctx, &p), ShouldBeNil)
+
+			vf := New(func(f *Form) {
+				f.CheckMode = CheckValue
+			})
+			So(vf.Check(&p, nil), ShouldBeNil)
+			So(vf.Check(&profile{}, nil).Error(), ShouldEqual, "年龄不能为空")
+
+			ctx = makeContext(url.Values{
+				"age":          []string{"-1"},
+				"address.city": []string{"Shenzhen"},
+				"birthday":     []string{"2020-01-02"},
+			})
+			p = profile{}
+			So(Bind(ctx, &p), ShouldBeNil)
+			So(p.Address, ShouldResemble, &address{City: "Shenzhen"})
+			So(p.Birthday.Format(dateLayout), ShouldEqual, "2020-01-02")
+			So(Check(ctx, &p).Error(), ShouldEqual, "年龄不能小于0")
+
+			p.Address.City = ""
+			*p.Age = 1
+			So(vf.Check(&p, nil).Error(), ShouldEqual, "城市不能为空")
+
+			//key存在但值为空时指向零值，不使用默认值
+			p = profile{}
+			So(BindSource(ValuesSource(url.Values{
+				"nickname": []string{""},
+				"level":    []string{""},
+			}), &p), ShouldBeNil)
+			So(*p.Nickname, ShouldEqual, "")
+			So(*p.Level, ShouldEqual, 0)
+			So(p.Age, ShouldBeNil)
+			So(p.Address, ShouldBeNil)
+
+			p = profile{}
+			So(BindSource(ValuesSource(url.Values{"address.city": []string{""}}), &p), ShouldBeNil)
+			So(p.Address, ShouldResemble, &address{})
+		})
+
+		Convey("测试上传文件", func() {
+			type upload struct {
+				Name    string                  `form:"name"`
+				Avatar  *multipart.FileHeader   `form:"avatar" title:"头像" valid:"required;filesize:1KB;mimetype:image/png,image/jpeg"`
+				Attachs []*multipart.FileHeader `form:"attach" title:"附件" valid:"max:2;ext:pdf,docx"`
+			}
+			png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 16)
+			ctx := makeMultipartContext(url.Values{"name": []string{"foo"}}, map[string][]string{
+				"avatar": {"a.png", png},
+				"attach": {"a.pdf", "%PDF-1.4", "b.DOCX", "PK"},
+			})
+			var u upload
+			So(Bind(ctx, &u), ShouldBeNil)
+			So(u.Name, ShouldEqual, "foo")
+			So(u.Avatar, ShouldNotBeNil)
+			So(u.Avatar.Filename, ShouldEqual, "a.png")
+			So(len(u.Attachs), ShouldEqual, 2)
+			So(Check(ctx, &u), ShouldBeNil)
+
+			vf := New(func(f *Form) {
+				f.CheckMode = CheckValue
+			})
+			So(vf.Check(&u, nil), ShouldBeNil)
+			So(vf.Check(&upload{}, nil).Error(), ShouldEqual, "头像不能为空")
+
+			ctx = makeMultipartContext(nil, map[string][]string{
+				"avatar": {"a.png", "GIF89a"},
+			})
+			So(Check(ctx, &u).Error(), ShouldEqual, "头像的文件类型必须为image/png,image/jpeg")
+
+			ctx = makeMultipartContext(nil, map[string][]string{
+				"avatar": {"a.png", png + strings.Repeat("\x00", 1024)},
+			})
+			So(Check(ctx, &u).Error(), ShouldEqual, "头像的大小不能超过1KB")
+
+			ctx = makeMultipartContext(nil, map[string][]string{
+				"avatar": {"a.png", png},
+				"attach": {"a.exe", "MZ"},
+			})
+			So(Check(ctx, &u).Error(), ShouldEqual, "附件的扩展名必须为pdf,docx")
+
+			ctx = makeMultipartContext(nil, map[string][]string{
+				"avatar": {"a.png", png},
+				"attach": {"a.pdf", "", "b.pdf", "", "c.pdf", ""},
+			})
+			So(Check(ctx, &u).Error(), ShouldEqual, "附件不能超过2项")
+		})
+
+		Convey("测试encoding.TextUnmarshaler", func() {
+			type foo struct {
+				Price    money    `form:"price" title:"价格" valid:"required"`
+				Discount *money   `form:"discount"`
+				Status   status   `form:"status" title:"状态"`
+				Statuses []status `form:"statuses"`
+				IP       net.IP   `form:"ip"`
+			}
+			var f foo
+			ctx := makeContext(url.Values{
+				"price":    []string{"12.5"},
+				"discount": []string{"0.99"},
+				"status":   []string{"active"},
+				"statuses": []string{"active", "disabled"},
+				"ip":       []string{"192.168.1.1"},
+			})
+			So(Bind(ctx, &f), ShouldBeNil)
+			So(f.Price, ShouldResemble, money{1250})
+			So(f.Discount, ShouldResemble, &money{99})
+			So(f.Status, ShouldEqual, status(1))
+			So(f.Statuses, ShouldResemble, []status{1, 2})
+			So(f.IP.String(), ShouldEqual, "192.168.1.1")
+			So(Check(ctx, &f), ShouldBeNil)
+
+			vf := New(func(f *Form) {
+				f.CheckMode = CheckValue
+			})
+			So(vf.Check(&f, nil), ShouldBeNil)
+
+			ctx = makeContext(url.Values{
+				"status": []string{"deleted"},
+			})
+			err := Bind(ctx, &f)
+			So(err, ShouldNotBeNil)
+			fe := err.(*FieldError)
+			So(fe.Rule, ShouldEqual, "format")
+			So(fe.Message, ShouldEqual, "状态的格式错误")
+			So(errors.Unwrap(fe).Error(), ShouldEqual, `unknown status "deleted"`)
+		})
+
+		Convey("测试自定义转换函数", func() {
+			type (
+				decimal struct {
+					Int  int64
+					Frac int64
+				}
+				uuid [4]byte
+				foo  struct {
+					Amount  decimal   `form:"amount" title:"金额" valid:"required"`
+					Amounts []decimal `form:"amounts"`
+					Fee     *decimal  `form:"fee"`
+					ID      uuid      `form:"id" title:"ID"`
+				}
+			)
+			parseDecimal := func(input string) (reflect.Value, error) {
+				parts := strings.SplitN(input, ".", 2)
+				var d decimal
+				var err error
+				if d.Int, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+					return reflect.Value{}, err
+				}
+				if len(parts) == 2 {
+					if d.Frac, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+						return reflect.Value{}, err
+					}
+				}
+				return reflect.ValueOf(d), nil
+			}
+			cf := New()
+			cf.RegisterConverter(reflect.TypeOf(decimal{}), parseDecimal)
+			cf.RegisterConverter(reflect.TypeOf(uuid{}), func(input string) (reflect.Value, error) {
+				var id uuid
+				if len(input) != len(id) {
+					return reflect.Value{}, fmt.Errorf("bad uuid %s", input)
+				}
+				copy(id[:], input)
+				return reflect.ValueOf(id), nil
+			})
+			var f foo
+			ctx := makeContext(url.Values{
+				"amount":  []string{"12.34"},
+				"amounts": []string{"1.1", "2.2"},
+				"fee":     []string{"0.5"},
+				"id":      []string{"abcd"},
+			})
+			So(cf.Bind(&f, ctx), ShouldBeNil)
+			So(f.Amount, ShouldResemble, decimal{12, 34})
+			So(f.Amounts, ShouldResemble, []decimal{{1, 1}, {2, 2}})
+			So(f.Fee, ShouldResemble, &decimal{0, 5})
+			So(f.ID, ShouldResemble, uuid{'a', 'b', 'c', 'd'})
+			So(cf.Check(&f, ctx), ShouldBeNil)
+
+			err := cf.Bind(&f, makeContext(url.Values{"id": []string{"abc"}}))
+			So(err, ShouldNotBeNil)
+			So(err.(*FieldError).Rule, ShouldEqual, "format")
+			So(err.Error(), ShouldEqual, "ID的格式错误")
+
+			type bar struct {
+				Amount decimal `form:"amount"`
+			}
+			lf := New()
+			var b bar
+			So(lf.Bind(&b, makeContext(url.Values{"amount.Int": []string{"1"}})), ShouldBeNil) //未注册时逐个字段绑定
+			So(b.Amount, ShouldResemble, decimal{1, 0})
+			lf.RegisterConverter(reflect.TypeOf(decimal{}), parseDecimal)
+			b = bar{}
+			So(lf.Bind(&b, makeContext(url.Values{"amount": []string{"3.5"}})), ShouldBeNil) //已缓存的类型也使用新的转换函数
+			So(b.Amount, ShouldResemble, decimal{3, 5})
+
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					lf.RegisterConverter(reflect.TypeOf(decimal{}), parseDecimal)
+				}()
+				go func() {
+					defer wg.Done()
+					var b bar
+					lf.Bind(&b, makeContext(url.Values{"amount": []string{"3.5"}}))
+				}()
+			}
+			wg.Wait()
+		})
+
 		Convey("测试错误的输入", func() {
 			type (
 				foo struct {
@@ -576,6 +1422,286 @@ func TestBind(t *testing.T) {
 			So(err, ShouldNotBeNil)
 		})
 
+		Convey("测试时间格式", func() {
+			type foo struct {
+				RFC3339 time.Time   `form:"rfc3339"`
+				Custom  time.Time   `form:"custom" time_format:"2006/01/02"`
+				Millis  time.Time   `form:"millis" time_unit:"ms"`
+				Local   time.Time   `form:"local"`
+				Dates   []time.Time `form:"dates" time_format:"20060102"`
+			}
+			shanghai := time.FixedZone("CST", 8*3600)
+			tf := New(func(f *Form) {
+				f.Location = shanghai
+			})
+			var f foo
+			ctx := makeContext(url.Values{
+				"rfc3339": []string{"2020-01-02T03:04:05.5+09:00"},
+				"custom":  []string{"2020/01/02"},
+				"millis":  []string{"1577894400123"},
+				"local":   []string{"2020-01-02 03:04:05"},
+				"dates":   []string{"20200102", "20200103"},
+			})
+			So(tf.Bind(&f, ctx), ShouldBeNil)
+			So(f.RFC3339.Equal(time.Date(2020, 1, 2, 3, 4, 5, 5e8, time.FixedZone("", 9*3600))), ShouldBeTrue)
+			So(f.Custom, ShouldResemble, time.Date(2020, 1, 2, 0, 0, 0, 0, shanghai))
+			So(f.Millis, ShouldResemble, time.Unix(1577894400, 123e6).In(shanghai))
+			So(f.Local, ShouldResemble, time.Date(2020, 1, 2, 3, 4, 5, 0, shanghai))
+			So(f.Dates, ShouldResemble, []time.Time{
+				time.Date(2020, 1, 2, 0, 0, 0, 0, shanghai),
+				time.Date(2020, 1, 3, 0, 0, 0, 0, shanghai),
+			})
+
+			err := tf.Bind(&f, makeContext(url.Values{"custom": []string{"2020-01-02"}}))
+			So(err, ShouldNotBeNil)
+			So(err.(*FieldError).Rule, ShouldEqual, "time")
+			err = tf.Bind(&f, makeContext(url.Values{"millis": []string{"2020-01-02"}}))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("测试时长和大小", func() {
+			type config struct {
+				Timeout time.Duration  `form:"timeout" title:"超时" valid:"range:1s,1h"`
+				Retry   *time.Duration `form:"retry"`
+				Quota   int64          `form:"quota" title:"配额" unit:"bytes" valid:"max:100MB"`
+				Limit   uint32         `form:"limit" unit:"bytes"`
+			}
+			var c config
+			ctx := makeContext(url.Values{
+				"timeout": []string{"30s"},
+				"retry":   []string{"1m30s"},
+				"quota":   []string{"10MB"},
+				"limit":   []string{"1.5k"},
+			})
+			So(Bind(ctx, &c), ShouldBeNil)
+			So(c.Timeout, ShouldEqual, 30*time.Second)
+			So(*c.Retry, ShouldEqual, 90*time.Second)
+			So(c.Quota, ShouldEqual, 10<<20)
+			So(c.Limit, ShouldEqual, 1536)
+			So(Check(ctx, &c), ShouldBeNil)
+
+			vf := New(func(f *Form) {
+				f.CheckMode = CheckValue
+			})
+			So(vf.Check(&c, nil), ShouldBeNil)
+			c.Timeout = 2 * time.Hour
+			So(vf.Check(&c, nil).Error(), ShouldEqual, "超时不能大于1h")
+
+			ctx = makeContext(url.Values{
+				"timeout": []string{"500ms"},
+				"quota":   []string{"1GB"},
+			})
+			err := CheckAll(ctx, &c)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "超时不能小于1s;配额不能大于100MB")
+
+			err = Bind(makeContext(url.Values{"timeout": []string{"30"}}), &c)
+			So(err, ShouldBeNil)
+			So(c.Timeout, ShouldEqual, 30*time.Nanosecond)
+			err = Bind(makeContext(url.Values{"timeout": []string{"abc"}}), &c)
+			So(err.(*FieldError).Rule, ShouldEqual, "duration")
+			err = Bind(makeContext(url.Values{"quota": []string{"10XB"}}), &c)
+			So(err.(*FieldError).Rule, ShouldEqual, "size")
+			err = Bind(makeContext(url.Values{"limit": []string{"8GB"}}), &c)
+			So(err.(*FieldError).Rule, ShouldEqual, "overflow")
+			//2^63字节，float64(math.MaxInt64)就是2^63，不能转换为int64
+			err = Bind(makeContext(url.Values{"quota": []string{"8388608.0TB"}}), &c)
+			So(err, ShouldNotBeNil)
+			So(err.(*FieldError).Rule, ShouldEqual, "size")
+			err = Bind(makeContext(url.Values{"quota": []string{"-1KB"}}), &c)
+			So(err, ShouldNotBeNil)
+			So(err.(*FieldError).Rule, ShouldEqual, "size")
+		})
+
+		Convey("测试值的来源", func() {
+			type request struct {
+				ID      int64  `form:"id" from:"param" valid:"required"`
+				Verbose bool   `form:"verbose" from:"query"`
+				Tenant  string `form:"X-Tenant" from:"header" title:"租户" valid:"required"`
+				Session string `form:"session" from:"cookie"`
+				Lang    string `form:"lang" from:"query,cookie"`
+				Name    string `form:"name"`
+			}
+			newContext := func() echo.Context {
+				req := httptest.NewRequest(http.MethodPost, "/users/42?verbose=1&name=query", strings.NewReader("name=body&id=1"))
+				req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+				req.Header.Set("X-Tenant", "acme")
+				req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+				req.AddCookie(&http.Cookie{Name: "lang", Value: "en"})
+				ctx := echo.New().NewContext(req, httptest.NewRecorder())
+				ctx.SetParamNames("id")
+				ctx.SetParamValues("42")
+				return ctx
+			}
+			var r request
+			ctx := newContext()
+			So(Bind(ctx, &r), ShouldBeNil)
+			So(Check(ctx, &r), ShouldBeNil)
+			So(r, ShouldResemble, request{
+				ID:      42,
+				Verbose: true,
+				Tenant:  "acme",
+				Session: "abc",
+				Lang:    "en",
+				Name:    "body",
+			})
+
+			ctx = newContext()
+			ctx.Request().Header.Del("X-Tenant")
+			So(Check(ctx, &r).Error(), ShouldEqual, "租户不能为空")
+
+			var q request
+			qf := New(func(f *Form) {
+				f.Sources = []string{SourceQuery, SourceForm}
+			})
+			So(qf.Bind(&q, newContext()), ShouldBeNil)
+			So(q.Name, ShouldEqual, "query")
+		})
+
+		Convey("测试ValueSource", func() {
+			type request struct {
+				ID      int64    `form:"id" title:"ID" valid:"required"`
+				Tags    []string `form:"tag"`
+				Verbose bool     `form:"verbose" from:"query"`
+				Tenant  string   `form:"X-Tenant" from:"header"`
+				Session string   `form:"session" from:"cookie"`
+			}
+			req := httptest.NewRequest(http.MethodPost, "/users?verbose=1", strings.NewReader("id=42&tag=a&tag=b"))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			req.Header.Set("X-Tenant", "acme")
+			req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+			var r request
+			src := RequestSource(req)
+			So(BindSource(src, &r), ShouldBeNil)
+			So(CheckSource(src, &r), ShouldBeNil)
+			So(r, ShouldResemble, request{
+				ID:      42,
+				Tags:    []string{"a", "b"},
+				Verbose: true,
+				Tenant:  "acme",
+				Session: "abc",
+			})
+
+			req = httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.Header.Set("Accept-Language", "en")
+			err := CheckAllSource(RequestSource(req), &r)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "ID is required")
+
+			//url.Values没有子来源，忽略from tag
+			var v request
+			src = ValuesSource(url.Values{
+				"id":       []string{"7"},
+				"tag":      []string{"x"},
+				"verbose":  []string{"true"},
+				"X-Tenant": []string{"t"},
+			})
+			So(BindSource(src, &v), ShouldBeNil)
+			So(v, ShouldResemble, request{ID: 7, Tags: []string{"x"}, Verbose: true, Tenant: "t"})
+			So(src.Has("id"), ShouldBeTrue)
+			So(src.Has("session"), ShouldBeFalse)
+			So(CheckSource(ValuesSource(url.Values{}), &v).Error(), ShouldEqual, "ID不能为空")
+
+			var files struct {
+				Avatar *multipart.FileHeader `form:"avatar"`
+			}
+			ctx := makeMultipartContext(nil, map[string][]string{"avatar": {"a.png", "png"}})
+			So(BindSource(RequestSource(ctx.Request()), &files), ShouldBeNil)
+			So(files.Avatar, ShouldNotBeNil)
+			So(files.Avatar.Filename, ShouldEqual, "a.png")
+		})
+
+		Convey("测试配置", func() {
+			type database struct {
+				Host string `form:"host" default:"localhost"`
+				Port int    `form:"port" title:"端口" valid:"range:1,65535" default:"3306"`
+			}
+			type config struct {
+				Name    string        `form:"name" title:"名称" valid:"required"`
+				Timeout time.Duration `form:"timeout" default:"30s"`
+				Hosts   []string      `form:"hosts" sep:","`
+				DB      database      `form:"db"`
+			}
+			os.Setenv("TEST_APP_NAME", "demo")
+			os.Setenv("TEST_APP_HOSTS", "a,b")
+			os.Setenv("TEST_APP_DB_PORT", "3307")
+			defer func() {
+				os.Unsetenv("TEST_APP_NAME")
+				os.Unsetenv("TEST_APP_HOSTS")
+				os.Unsetenv("TEST_APP_DB_PORT")
+			}()
+			var c config
+			So(BindEnv(&c, "TEST_APP_"), ShouldBeNil)
+			So(c, ShouldResemble, config{
+				Name:    "demo",
+				Timeout: 30 * time.Second,
+				Hosts:   []string{"a", "b"},
+				DB:      database{Host: "localhost", Port: 3307},
+			})
+
+			var m config
+			So(BindMap(&m, map[string]string{"name": "demo", "db.host": "db"}), ShouldBeNil)
+			So(m.DB, ShouldResemble, database{Host: "db", Port: 3306})
+
+			err := BindMap(&m, map[string]string{"db.port": "70000"})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "名称不能为空;端口不能大于65535")
+			err = BindMap(&m, map[string]string{"name": "demo", "db.port": "abc"})
+			So(err.(*FieldError).Key, ShouldEqual, "db.port")
+		})
+
+		Convey("测试tag选项", func() {
+			type user struct {
+				NickName string `json:"nick_name,omitempty" valid:"required"`
+				Age      int    `json:"age,string" valid:"max:150"`
+				Admin    bool   `form:"-" json:"admin" valid:"required"`
+				Secret   string `json:"-"`
+				Dash     string `json:"-,"`
+				Name     string `json:",omitempty"`
+			}
+			var u user
+			ctx := makeContext(url.Values{
+				"nick_name": []string{"jia"},
+				"age":       []string{`"24"`},
+				"admin":     []string{"true"},
+				"Secret":    []string{"s"},
+				"-":         []string{"dash"},
+				"Name":      []string{"name"},
+			})
+			So(Bind(ctx, &u), ShouldBeNil)
+			So(u, ShouldResemble, user{NickName: "jia", Age: 24, Dash: "dash", Name: "name"})
+			So(Check(ctx, &u), ShouldBeNil)
+
+			ctx = makeContext(url.Values{"age": []string{`"200"`}})
+			err := CheckAll(ctx, &u)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "nick_name不能为空;age不能大于150")
+		})
+
+		Convey("测试并发", func() {
+			f := New()
+			var wg sync.WaitGroup
+			errs := make(chan error, 16)
+			for i := 0; i < 16; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					var o benchForm
+					ctx := benchContext()
+					if err := f.Bind(&o, ctx); err != nil {
+						errs <- err
+						return
+					}
+					errs <- f.Check(&o, ctx)
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				So(err, ShouldBeNil)
+			}
+		})
+
 		Convey("测试time.Time", func() {
 			type foo struct {
 				Unixtime time.Time `json:"unixtime"`
@@ -603,3 +1729,97 @@ func TestBind(t *testing.T) {
 
 	})
 }
+
+type benchAddress struct {
+	City   string `form:"city" title:"城市" valid:"required;max:32"`
+	Street string `form:"street" title:"街道" valid:"max:64"`
+}
+
+type benchForm struct {
+	UserName string       `form:"username" title:"用户名" valid:"required;username;range:4,16"`
+	Email    string       `form:"email" title:"邮箱" valid:"required;email"`
+	Age      int          `form:"age" title:"年龄" valid:"range:18,120" default:"18"`
+	Weight   float64      `form:"weight" title:"体重" valid:"max:300"`
+	Tags     []string     `form:"tag" title:"标签" valid:"max:5"`
+	Birthday time.Time    `form:"birthday" title:"生日"`
+	Shipping benchAddress `form:"shipping"`
+}
+
+func benchContext() echo.Context {
+	return makeContext(url.Values{
+		"username":      []string{"jiazhoulvke"},
+		"email":         []string{"jiazhoulvke@example.com"},
+		"age":           []string{"24"},
+		"weight":        []string{"60.5"},
+		"tag":           []string{"a", "b", "c"},
+		"birthday":      []string{"2007-12-13"},
+		"shipping.city": []string{"Shanghai"},
+	})
+}
+
+func BenchmarkBind(b *testing.B) {
+	ctx := benchContext()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var o benchForm
+		if err := Bind(ctx, &o); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCheck(b *testing.B) {
+	ctx := benchContext()
+	var o benchForm
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Check(ctx, &o); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCheckValue(b *testing.B) {
+	f := New(func(f *Form) {
+		f.CheckMode = CheckValue
+	})
+	var o benchForm
+	if err := Bind(benchContext(), &o); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := f.Check(&o, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+//BenchmarkBindNoCache 每次使用新的Form，相当于没有缓存struct信息时的绑定
+func BenchmarkBindNoCache(b *testing.B) {
+	ctx := benchContext()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var o benchForm
+		if err := New().Bind(&o, ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+//BenchmarkCheckNoCache 每次使用新的Form，相当于没有缓存struct信息和解析好的参数时的校验
+func BenchmarkCheckNoCache(b *testing.B) {
+	ctx := benchContext()
+	var o benchForm
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := New().Check(&o, ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,630 @@
+package form
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//Generated echo-formgen为一个struct类型生成的绑定和校验函数，o为指向该类型的指针。
+//
+//本文件中以Gen开头的函数和类型、TagInfo等只供生成的代码和echo-formgen使用，随生成器变化，
+//不保证兼容。升级echo-form后需要重新生成，生成器的输出有变化时genVersion会增加，
+//旧的生成代码的Hash不再一致，在重新生成之前使用反射
+type Generated struct {
+	Bind  func(f *Form, o interface{}, src ValueSource) error
+	Check func(f *Form, o interface{}, src ValueSource, errs *ValidationErrors) error
+	//Hash 生成代码时struct的字段名、类型和tag的摘要，见GenHash
+	Hash string
+}
+
+//genVersion 生成代码的版本，修改echo-formgen生成的代码或者它使用的Gen函数的行为时增加
+const genVersion = 1
+
+var (
+	generated sync.Map
+	//staleGenerated 因为Hash不一致而没有注册的类型，Validate将其作为错误报告
+	staleGenerated sync.Map
+)
+
+//RegisterGenerated 注册类型的生成代码，o为该类型的值或者指针，一般由生成的init函数调用。
+//注册后Form绑定和校验该类型时使用生成的代码，Form.NoGenerated为true或者修改了tag名时仍使用反射。
+//g.Hash与当前的类型不一致时说明修改struct后没有重新生成，不注册生成的代码，该类型继续使用反射
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func RegisterGenerated(o interface{}, g Generated) {
+	t := reflect.TypeOf(o)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if g.Hash != GenHash(describeStruct(New(), t)) {
+		generated.Delete(t)
+		staleGenerated.Store(t, true)
+		return
+	}
+	staleGenerated.Delete(t)
+	generated.Store(t, g)
+}
+
+//GenHash 返回struct描述的摘要。描述为{name type "tag";...}，依次列出所有字段的字段名、类型和以Go语法引用的tag，
+//逐个字段绑定的struct的类型为其描述，key为-的字段的类型为-，其它类型为reflect.Type.String()。
+//摘要包含genVersion，生成器的输出变化后旧的生成代码不再使用。echo-formgen按同样的规则生成Generated.Hash
+//供echo-formgen等工具使用，随生成器变化，不保证兼容
+func GenHash(desc string) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d", genVersion)
+	h.Write([]byte(desc))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+//describeStruct 按GenHash的规则描述struct t，f为使用默认tag名的Form
+func describeStruct(f *Form, t reflect.Type) string {
+	var b strings.Builder
+	b.WriteString("{")
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		typ := "-"
+		if !f.TagInfo(field).Skip {
+			if f.isNestedStruct(field.Type) {
+				typ = describeStruct(f, field.Type)
+			} else {
+				typ = field.Type.String()
+			}
+		}
+		fmt.Fprintf(&b, "%s %s %s;", field.Name, typ, strconv.Quote(string(field.Tag)))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+//lookupGenerated 返回o的类型注册的生成代码，o必须为指向struct的指针
+func (f *Form) lookupGenerated(o interface{}) (Generated, bool) {
+	if f.NoGenerated || !f.defaultTags() {
+		return Generated{}, false
+	}
+	t := reflect.TypeOf(o)
+	if t.Kind() != reflect.Ptr {
+		return Generated{}, false
+	}
+	g, ok := generated.Load(t.Elem())
+	if !ok {
+		return Generated{}, false
+	}
+	return g.(Generated), true
+}
+
+//useGeneratedCheck 是否可以使用生成的校验代码。生成的代码按默认的valid tag在生成时解析好规则，
+//只校验请求中的输入，并且直接调用内置的检测器，因此CheckValue模式、修改了ValidField或者覆盖了内置检测器时使用反射
+func (f *Form) useGeneratedCheck() bool {
+	return f.CheckMode == CheckInput && f.ValidField == "valid" &&
+		atomic.LoadInt32(&builtinOverridden) == 0 && atomic.LoadInt32(&f.builtinOverridden) == 0
+}
+
+//defaultTags 生成的代码按默认的tag名解析tag，修改了tag名或者注册了转换函数时不能使用
+func (f *Form) defaultTags() bool {
+	return equalStrings(f.FormFields, []string{"form", "json"}) &&
+		equalStrings(f.LabelFields, []string{"title", "label", "json"}) &&
+		f.DefaultField == "default" &&
+		f.MessageField == "msg" &&
+		f.SepField == "sep" &&
+		f.TimeFormatField == "time_format" &&
+		f.TimeUnitField == "time_unit" &&
+		f.UnitField == "unit" &&
+		f.FromField == "from" &&
+		!f.hasConverters()
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+//TagInfo 按Form的tag设置从struct tag中解析出的字段信息
+//供echo-formgen等工具使用，随生成器变化，不保证兼容
+type TagInfo struct {
+	//Key 字段的key，不含前缀
+	Key        string
+	Title      string
+	Default    string
+	Message    string
+	From       string
+	Sep        string
+	TimeFormat string
+	TimeUnit   string
+	Unit       string
+	//Skip 字段的key为-，不绑定也不校验
+	Skip bool
+	//Quoted 设置了string选项
+	Quoted bool
+	//Rules valid tag中的规则
+	Rules []TagRule
+}
+
+//TagRule valid tag中的一个规则，如range:1,10
+//供echo-formgen等工具使用，随生成器变化，不保证兼容
+type TagRule struct {
+	Name   string
+	Params []string
+}
+
+//TagInfo 返回字段的tag信息，供echo-formgen等工具使用
+//供echo-formgen等工具使用，随生成器变化，不保证兼容
+func (f *Form) TagInfo(field reflect.StructField) TagInfo {
+	return TagInfo{
+		Key:        defaultField(field, f.FormFields),
+		Title:      defaultField(field, f.LabelFields),
+		Default:    field.Tag.Get(f.DefaultField),
+		Message:    field.Tag.Get(f.MessageField),
+		From:       field.Tag.Get(f.FromField),
+		Sep:        field.Tag.Get(f.SepField),
+		TimeFormat: field.Tag.Get(f.TimeFormatField),
+		TimeUnit:   field.Tag.Get(f.TimeUnitField),
+		Unit:       field.Tag.Get(f.UnitField),
+		Skip:       f.skipField(field),
+		Quoted:     f.quoted(field),
+		Rules:      f.tagRules(field),
+	}
+}
+
+func (f *Form) tagRules(field reflect.StructField) []TagRule {
+	var rules []TagRule
+	for _, r := range f.parseRules(field) {
+		if r.Name != "" {
+			rules = append(rules, TagRule{Name: r.Name, Params: r.Params})
+		}
+	}
+	return rules
+}
+
+//Default 返回包级函数Bind、Check等使用的Form
+func Default() *Form {
+	return form
+}
+
+//以下函数供echo-formgen生成的代码使用，与反射绑定和校验使用相同的实现
+
+//GenKey 将嵌套struct中字段的key加上前缀
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (f *Form) GenKey(prefix, name string) string {
+	return f.joinKey(prefix, name)
+}
+
+//GenInput 按来源读取key对应的第一个值，from为字段的from tag
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (f *Form) GenInput(src ValueSource, key, from string, quoted bool) string {
+	fm := f.genMeta(from, "", quoted)
+	return f.formValue(&fm, key, src)
+}
+
+//GenHas 按来源判断请求中是否有key，值可以为空，用于指针字段
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (f *Form) GenHas(src ValueSource, key, from string) bool {
+	fm := f.genMeta(from, "", false)
+	return f.hasValue(&fm, key, src)
+}
+
+//GenInputs 读取slice字段的所有值，sep为字段的sep tag
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (f *Form) GenInputs(src ValueSource, key, from string, quoted bool, sep string) []string {
+	fm := f.genMeta(from, sep, quoted)
+	return f.sliceInputs(&fm, key, src)
+}
+
+func (f *Form) genMeta(from, sep string, quoted bool) fieldMeta {
+	fm := fieldMeta{sources: f.Sources, sep: sep, quoted: quoted}
+	if from != "" {
+		fm.sources = strings.Split(from, ",")
+	}
+	return fm
+}
+
+//GenError 将GenInt等函数返回的转换错误包装为FieldError，msg为字段的msg tag
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (f *Form) GenError(src ValueSource, field, key, title, input, msg string, err error) error {
+	return f.convertError(src, field, key, title, input, parseMessageTag(msg), err)
+}
+
+//GenTime 按time_format或者time_unit解析时间
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (f *Form) GenTime(input, format, unit string) (time.Time, error) {
+	t, err := f.parseTimeAs(input, format, unit)
+	if err != nil {
+		return t, ruleError{rule: "time", err: err}
+	}
+	return t, nil
+}
+
+//GenInt 将input转换为bits位的有符号整数，bits为0时为int的位数，bytes为true时input可以是带单位的大小
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func GenInt(input string, bits int, bytes bool) (int64, error) {
+	if bits == 0 {
+		bits = intBits
+	}
+	return parseInt(input, bits, bytes)
+}
+
+//GenUint 将input转换为bits位的无符号整数，bits为0时为uint的位数，bytes为true时input可以是带单位的大小
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func GenUint(input string, bits int, bytes bool) (uint64, error) {
+	if bits == 0 {
+		bits = intBits
+	}
+	return parseUint(input, bits, bytes)
+}
+
+//GenFloat 将input转换为bits位的浮点数
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func GenFloat(input string, bits int) (float64, error) {
+	return parseFloat(input, bits)
+}
+
+//GenDuration 将input转换为time.Duration
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func GenDuration(input string) (time.Duration, error) {
+	d, err := parseDuration(input)
+	if err != nil {
+		return 0, ruleError{rule: "duration", err: err}
+	}
+	return time.Duration(d), nil
+}
+
+const intBits = 32 << (^uint(0) >> 63)
+
+//GenField 生成的校验代码中正在校验的字段，对应checkField中的一个字段
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+type GenField struct {
+	f      *Form
+	src    ValueSource
+	errs   *ValidationErrors
+	prefix string
+	name   string
+	key    string
+	title  string
+	//msg 字段的msg tag，只在校验失败时解析
+	msg   string
+	input string
+	//count slice字段的值的个数
+	count int
+}
+
+//GenField 返回字段的校验信息，name为字段名，input为字段的输入，msg为字段的msg tag
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (f *Form) GenField(src ValueSource, errs *ValidationErrors, prefix, name, key, title, input, msg string) GenField {
+	return GenField{f: f, src: src, errs: errs, prefix: prefix, name: name, key: key, title: title, msg: msg, input: input}
+}
+
+//GenSliceField 同GenField，inputs为slice字段的所有值
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (f *Form) GenSliceField(src ValueSource, errs *ValidationErrors, prefix, name, key, title string, inputs []string, msg string) GenField {
+	c := f.GenField(src, errs, prefix, name, key, title, strings.Join(inputs, ","), msg)
+	c.count = len(inputs)
+	return c
+}
+
+//report 与checkField一致：将校验失败的错误包装为FieldError，errs为nil时返回，否则追加到errs中
+func (c GenField) report(rule, params string, err error) error {
+	fe := newFieldError(err)
+	var ps []string
+	if params != "" {
+		ps = strings.Split(params, ",")
+	}
+	fe.fill(c.name, c.key, c.title, rule, ps, c.input)
+	if msg, ok := parseMessageTag(c.msg)[fe.Rule]; ok {
+		fe.Message = msg
+	}
+	if c.errs == nil {
+		return fe
+	}
+	*c.errs = append(*c.errs, fe)
+	return nil
+}
+
+//fail 同Context.Fail
+func (c GenField) fail(key string, args ...interface{}) error {
+	return &FieldError{
+		Message: c.f.translate(c.src, key, append([]interface{}{c.title}, args...)...),
+	}
+}
+
+//Required 字段不能为空。rule为报错时的规则名，required_if等规则的条件由生成的代码判断
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (c GenField) Required(rule, params string) error {
+	if !IsRequired(c.input) {
+		return c.report(rule, params, c.fail("required"))
+	}
+	return nil
+}
+
+//Rule 使用只读取输入的内置检测器校验字段，如Email、Mobile
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (c GenField) Rule(rule, params string, check CheckFunc) error {
+	ctx := Context{
+		Input:  c.input,
+		Key:    c.key,
+		Title:  c.title,
+		Source: c.src,
+		Ctx:    echoContext(c.src),
+		form:   c.f,
+		prefix: c.prefix,
+	}
+	if err := check(ctx); err != nil {
+		return c.report(rule, params, err)
+	}
+	return nil
+}
+
+//GenLimit echo-formgen生成代码时按字段类型解析好的min、max、range规则
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+type GenLimit struct {
+	//Rule 规则名
+	Rule string
+	//Params 以,连接的参数
+	Params string
+	//Kind 比较的对象：int、float、len(字符串的长度)或者count(slice的值的个数)
+	Kind string
+	//Unit 整数字段输入的格式：integer、duration或者size
+	Unit string
+	//Min 下限，为nil时不检查
+	Min *GenBound
+	//Max 上限，为nil时不检查
+	Max *GenBound
+}
+
+//GenBound GenLimit的下限或者上限
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+type GenBound struct {
+	//Int 整数、长度或者个数
+	Int   int64
+	Float float64
+	//Text 错误信息中显示的值
+	Text string
+}
+
+//GenLimit 按字段的类型解析min、max、range规则，供echo-formgen使用，与MinOrMax的解析一致
+//供echo-formgen等工具使用，随生成器变化，不保证兼容
+func (f *Form) GenLimit(field reflect.StructField, r TagRule) (GenLimit, error) {
+	if r.Name != "min" && r.Name != "max" && r.Name != "range" {
+		return GenLimit{}, fmt.Errorf("%s不是min、max或range", r.Name)
+	}
+	if err := f.validateRule(field, rule{Name: r.Name, Params: r.Params}, nil); err != nil {
+		return GenLimit{}, err
+	}
+	if field.Type.Kind() == reflect.Ptr && !isFileType(field.Type) {
+		field.Type = field.Type.Elem()
+	}
+	l := GenLimit{Rule: r.Name, Params: strings.Join(r.Params, ",")}
+	switch {
+	case IsIntType(field):
+		l.Kind = "int"
+		_, l.Unit = f.intParser(field)
+	case IsFloatType(field):
+		l.Kind = "float"
+	case IsStringType(field):
+		l.Kind = "len"
+	default:
+		l.Kind = "count"
+	}
+	bound := func(param string) *GenBound {
+		p := f.parseLimit(field, param)
+		switch l.Kind {
+		case "int":
+			b := &GenBound{Int: p.i, Text: param}
+			if l.Unit == "integer" {
+				b.Text = strconv.FormatInt(p.i, 10)
+			}
+			return b
+		case "float":
+			return &GenBound{Float: p.f, Text: fmt.Sprintf("%f", p.f)}
+		}
+		return &GenBound{Int: int64(p.n), Text: strconv.Itoa(p.n)}
+	}
+	switch r.Name {
+	case "min":
+		l.Min = bound(r.Params[0])
+	case "max":
+		l.Max = bound(r.Params[0])
+	default:
+		l.Min, l.Max = bound(r.Params[0]), bound(r.Params[1])
+	}
+	return l, nil
+}
+
+//Limit 按生成代码时解析好的上下限校验字段，与MinOrMax一致
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (c GenField) Limit(l *GenLimit) error {
+	if c.input == "" {
+		return nil
+	}
+	switch l.Kind {
+	case "int":
+		v, err := parseIntUnit(l.Unit, c.input)
+		if err != nil {
+			return c.report(l.Rule, l.Params, c.fail(l.Unit))
+		}
+		if l.Min != nil && v < l.Min.Int {
+			return c.report(l.Rule, l.Params, c.fail("min", l.Min.Text))
+		}
+		if l.Max != nil && v > l.Max.Int {
+			return c.report(l.Rule, l.Params, c.fail("max", l.Max.Text))
+		}
+	case "float":
+		v, err := strconv.ParseFloat(c.input, 64)
+		if err != nil {
+			return c.report(l.Rule, l.Params, c.fail("float"))
+		}
+		if l.Min != nil && v < l.Min.Float {
+			return c.report(l.Rule, l.Params, c.fail("min", l.Min.Text))
+		}
+		if l.Max != nil && v > l.Max.Float {
+			return c.report(l.Rule, l.Params, c.fail("max", l.Max.Text))
+		}
+	default:
+		n, suffix := int64(len(c.input)), "_len"
+		if l.Kind == "count" {
+			n, suffix = int64(c.count), "_count"
+		}
+		if l.Min != nil && n < l.Min.Int {
+			return c.report(l.Rule, l.Params, c.fail("min"+suffix, l.Min.Text))
+		}
+		if l.Max != nil && n > l.Max.Int {
+			return c.report(l.Rule, l.Params, c.fail("max"+suffix, l.Max.Text))
+		}
+	}
+	return nil
+}
+
+//compare 与compareField一致。n为两个字段转换为字段类型后比较的结果，errX、errY为转换当前字段和title对应字段时的错误
+func (c GenField) compare(rule, params, title string, n int, errX, errY error) error {
+	if errY != nil {
+		//另一个字段的输入有误，由它自己的规则报错
+		return nil
+	}
+	if errX != nil {
+		if re, ok := errX.(ruleError); ok {
+			return c.report(rule, params, c.fail(re.rule))
+		}
+		return c.report(rule, params, errX)
+	}
+	if cmp := fieldComparisons[rule]; !cmp.ok(n) {
+		return c.report(rule, params, c.fail(cmp.key, title))
+	}
+	return nil
+}
+
+//CompareInt 比较整数字段与同级字段，title和other为另一个字段的标题和输入，x、y分别转换两个字段的输入
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (c GenField) CompareInt(rule, params, title, other string, x, y func(string) (int64, error)) error {
+	if c.input == "" {
+		return nil
+	}
+	var b int64
+	var errY error
+	if other != "" {
+		b, errY = y(other)
+	}
+	a, errX := x(c.input)
+	return c.compare(rule, params, title, compareInt(a, b), errX, errY)
+}
+
+//CompareUint 同CompareInt，用于无符号整数
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (c GenField) CompareUint(rule, params, title, other string, x, y func(string) (uint64, error)) error {
+	if c.input == "" {
+		return nil
+	}
+	var b uint64
+	var errY error
+	if other != "" {
+		b, errY = y(other)
+	}
+	a, errX := x(c.input)
+	return c.compare(rule, params, title, compareUint(a, b), errX, errY)
+}
+
+//CompareFloat 同CompareInt，用于浮点数
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (c GenField) CompareFloat(rule, params, title, other string, x, y func(string) (float64, error)) error {
+	if c.input == "" {
+		return nil
+	}
+	var b float64
+	var errY error
+	if other != "" {
+		b, errY = y(other)
+	}
+	a, errX := x(c.input)
+	return c.compare(rule, params, title, compareFloat(a, b), errX, errY)
+}
+
+//CompareTime 同CompareInt，用于time.Time
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (c GenField) CompareTime(rule, params, title, other string, x, y func(string) (time.Time, error)) error {
+	if c.input == "" {
+		return nil
+	}
+	var b time.Time
+	var errY error
+	if other != "" {
+		b, errY = y(other)
+	}
+	a, errX := x(c.input)
+	return c.compare(rule, params, title, compareTime(a, b), errX, errY)
+}
+
+//CompareString 比较字符串字段与同级字段
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (c GenField) CompareString(rule, params, title, other string) error {
+	if c.input == "" {
+		return nil
+	}
+	return c.compare(rule, params, title, strings.Compare(c.input, other), nil, nil)
+}
+
+//CompareBool 比较bool字段与同级字段，只能判断是否相等
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (c GenField) CompareBool(rule, params, title, other string) error {
+	if c.input == "" {
+		return nil
+	}
+	//与setValue一致，有值且不为false、0时为true，没有值时为零值false
+	a := c.input != "false" && c.input != "0"
+	b := other != "" && other != "false" && other != "0"
+	n := 0
+	if a != b {
+		n = 1
+	}
+	return c.compare(rule, params, title, n, nil, nil)
+}
+
+//Custom 使用名为rule的检测器校验字段，用于自定义的检测器。o为字段所在struct的指针，field为字段名，
+//parent为同级字段所在struct的指针。检测器可能读取Context中的Value等，因此这里使用反射
+//仅供echo-formgen生成的代码使用，随生成器变化，不保证兼容
+func (c GenField) Custom(rule, params string, parent, o interface{}, field string) error {
+	check, ok := c.f.checkFunc(rule)
+	if !ok {
+		return fmt.Errorf("检测器%s找不到", rule)
+	}
+	v := reflect.ValueOf(o).Elem()
+	fm, ok := c.f.structMeta(v.Type()).byName[field]
+	if !ok {
+		return fmt.Errorf("%s中找不到字段%s", v.Type(), field)
+	}
+	t, value := fm.field, v.Field(fm.index)
+	if t.Type.Kind() == reflect.Ptr && fm.kind != fileField {
+		value = indirect(value)
+		t.Type = t.Type.Elem()
+	}
+	var ps []string
+	if params != "" {
+		ps = strings.Split(params, ",")
+	}
+	ctx := Context{
+		Input:  c.input,
+		Key:    c.key,
+		Title:  c.title,
+		Params: ps,
+		Field:  t,
+		Value:  value,
+		Parent: reflect.ValueOf(parent).Elem(),
+		Source: c.src,
+		Ctx:    echoContext(c.src),
+		form:   c.f,
+		meta:   fm,
+		prefix: c.prefix,
+	}
+	if err := check(ctx); err != nil {
+		return c.report(rule, params, err)
+	}
+	return nil
+}
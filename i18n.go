@@ -0,0 +1,246 @@
+package form
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//DefaultLang 默认语言
+var DefaultLang = "zh-CN"
+
+//Translator 错误信息翻译器
+type Translator interface {
+	//Translate 将key对应的消息翻译为lang语言，找不到对应的语言或消息时ok为false
+	Translate(lang, key string, args ...interface{}) (msg string, ok bool)
+}
+
+//Messages 一种语言的消息模板，key为规则名，模板的第一个参数为字段标题。
+//模板不需要用到所有参数时可以使用%[1]s这样的显式下标
+type Messages map[string]string
+
+//Catalog 多语言消息目录，key为语言标签，如zh-CN、en
+type Catalog map[string]Messages
+
+//Translate 实现Translator。找不到完全匹配的语言时会依次尝试主语言(en-US -> en)以及同一主语言的其它地区(zh -> zh-CN)
+func (c Catalog) Translate(lang, key string, args ...interface{}) (string, bool) {
+	msgs, ok := c.lookup(lang)
+	if !ok {
+		return "", false
+	}
+	format, ok := msgs[key]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf(format, args...), true
+}
+
+//lookup 返回lang对应的消息。按语言标签排序后依次匹配，同一主语言有多个地区(如zh-CN、zh-TW)时结果是确定的。
+//排序的结果由sortedLangs缓存
+func (c Catalog) lookup(lang string) (Messages, bool) {
+	if msgs, ok := c[lang]; ok {
+		return msgs, true
+	}
+	langs := c.sortedLangs()
+	base := baseLang(lang)
+	for _, match := range []func(l string) bool{
+		func(l string) bool { return strings.EqualFold(l, lang) },
+		func(l string) bool { return strings.EqualFold(l, base) },
+		func(l string) bool { return strings.EqualFold(baseLang(l), base) },
+	} {
+		for _, l := range langs {
+			if match(l) {
+				return c[l], true
+			}
+		}
+	}
+	return nil, false
+}
+
+//catalogLangs 缓存Catalog排好序的语言标签，key为map的指针。Catalog是map，可能随时被修改，
+//使用前检查语言标签是否与缓存的一致。临时创建的Catalog不应让缓存无限增长，超过maxCatalogLangs时清空
+var (
+	catalogLangsMu sync.RWMutex
+	catalogLangs   = make(map[uintptr][]string)
+)
+
+const maxCatalogLangs = 64
+
+//sortedLangs 返回排好序的语言标签，只在第一次使用或者增删了语言后排序
+func (c Catalog) sortedLangs() []string {
+	key := reflect.ValueOf(c).Pointer()
+	catalogLangsMu.RLock()
+	langs, ok := catalogLangs[key]
+	catalogLangsMu.RUnlock()
+	if ok && c.hasLangs(langs) {
+		return langs
+	}
+	langs = make([]string, 0, len(c))
+	for l := range c {
+		langs = append(langs, l)
+	}
+	sort.Strings(langs)
+	catalogLangsMu.Lock()
+	if len(catalogLangs) >= maxCatalogLangs {
+		catalogLangs = make(map[uintptr][]string)
+	}
+	catalogLangs[key] = langs
+	catalogLangsMu.Unlock()
+	return langs
+}
+
+//hasLangs c的语言标签是否正好是langs
+func (c Catalog) hasLangs(langs []string) bool {
+	if len(langs) != len(c) {
+		return false
+	}
+	for _, l := range langs {
+		if _, ok := c[l]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func baseLang(lang string) string {
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		return lang[:i]
+	}
+	return lang
+}
+
+//DefaultCatalog 内置的消息目录
+var DefaultCatalog = Catalog{
+	"zh-CN": zhCNMessages,
+	"en":    enMessages,
+}
+
+var zhCNMessages = Messages{
+	"required":       "%s不能为空",
+	"min":            "%s不能小于%s",
+	"max":            "%s不能大于%s",
+	"min_len":        "%s的长度不能小于%s",
+	"max_len":        "%s的长度不能大于%s",
+	"min_count":      "%s至少需要%s项",
+	"max_count":      "%s不能超过%s项",
+	"alpha":          "%s只允许包含字母",
+	"numeric":        "%s只允许包含数字",
+	"alphanumeric":   "%s只允许包含数字或字母",
+	"alphadash":      "%s只允许包含数字或字母以及下划线",
+	"username_first": "%s的第一个字符必须为字母",
+	"username_last":  "%s的最后一个字符不能为_",
+	"float":          "%s必须为浮点数",
+	"integer":        "%s必须为整数",
+	"email":          "%s不是正确email格式",
+	"ipv4":           "%s必须为正确的IPv4格式",
+	"mobile":         "%s必须为正确的手机号码",
+	"tel":            "%s必须为正确的座机号码",
+	"phone":          "%s必须为正确的手机或座机号码",
+	"idcard":         "%s必须为正确的身份证号码",
+	"eqfield":        "%s必须与%s相同",
+	"nefield":        "%s不能与%s相同",
+	"gtfield":        "%s必须大于%s",
+	"ltfield":        "%s必须小于%s",
+	"filesize":       "%s的大小不能超过%s",
+	"mimetype":       "%s的文件类型必须为%s",
+	"ext":            "%s的扩展名必须为%s",
+	"overflow":       "%s的数值越界",
+	"format":         "%s的格式错误",
+	"duration":       "%s必须为正确的时长，如30s、1h",
+	"size":           "%s必须为正确的大小，如512KB、10MB",
+	"time":           "%s的时间格式错误",
+}
+
+var enMessages = Messages{
+	"required":       "%s is required",
+	"min":            "%s must not be less than %s",
+	"max":            "%s must not be greater than %s",
+	"min_len":        "%s must be at least %s characters long",
+	"max_len":        "%s must be at most %s characters long",
+	"min_count":      "%s must have at least %s items",
+	"max_count":      "%s must have at most %s items",
+	"alpha":          "%s may only contain letters",
+	"numeric":        "%s may only contain digits",
+	"alphanumeric":   "%s may only contain letters and digits",
+	"alphadash":      "%s may only contain letters, digits and underscores",
+	"username_first": "%s must start with a letter",
+	"username_last":  "%s must not end with an underscore",
+	"float":          "%s must be a number",
+	"integer":        "%s must be an integer",
+	"email":          "%s must be a valid email address",
+	"ipv4":           "%s must be a valid IPv4 address",
+	"mobile":         "%s must be a valid mobile number",
+	"tel":            "%s must be a valid telephone number",
+	"phone":          "%s must be a valid mobile or telephone number",
+	"idcard":         "%s must be a valid ID card number",
+	"eqfield":        "%s must match %s",
+	"nefield":        "%s must differ from %s",
+	"gtfield":        "%s must be greater than %s",
+	"ltfield":        "%s must be less than %s",
+	"filesize":       "%s must not be larger than %s",
+	"mimetype":       "%s must be a file of type %s",
+	"ext":            "%s must have one of the extensions %s",
+	"overflow":       "%s is out of range",
+	"format":         "%s has an invalid format",
+	"duration":       "%s must be a valid duration such as 30s or 1h",
+	"size":           "%s must be a valid size such as 512KB or 10MB",
+	"time":           "%s is not a valid time",
+}
+
+//translate 按请求的Accept-Language翻译消息，都找不到时使用Form.Lang
+func (f *Form) translate(src ValueSource, key string, args ...interface{}) string {
+	tr := f.Translator
+	if tr == nil {
+		tr = DefaultCatalog
+	}
+	for _, lang := range append(requestLangs(src), f.Lang) {
+		if msg, ok := tr.Translate(lang, key, args...); ok {
+			return msg
+		}
+	}
+	return key
+}
+
+//requestLangs 按权重从高到低返回Accept-Language中的语言
+func requestLangs(src ValueSource) []string {
+	header := sourceHeader(src, "Accept-Language")
+	if header == "" {
+		return nil
+	}
+	type weighted struct {
+		lang string
+		q    float64
+	}
+	items := make([]weighted, 0, 4)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		lang := strings.TrimSpace(fields[0])
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if n, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = n
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		items = append(items, weighted{lang, q})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].q > items[j].q
+	})
+	langs := make([]string, 0, len(items))
+	for _, item := range items {
+		langs = append(langs, item.lang)
+	}
+	return langs
+}
@@ -0,0 +1,706 @@
+// Code generated by echo-formgen. DO NOT EDIT.
+
+package gentest
+
+import (
+	"time"
+
+	form "github.com/jiazhoulvke/echo-form"
+)
+
+func init() {
+	form.RegisterGenerated((*Order)(nil), form.Generated{
+		Bind: func(f *form.Form, o interface{}, src form.ValueSource) error {
+			return formgenBindOrder(f, o.(*Order), src, "")
+		},
+		Check: func(f *form.Form, o interface{}, src form.ValueSource, errs *form.ValidationErrors) error {
+			return formgenCheckOrder(f, o.(*Order), src, "", errs)
+		},
+		Hash: "309df642f8278e2a",
+	})
+	form.RegisterGenerated((*Signup)(nil), form.Generated{
+		Bind: func(f *form.Form, o interface{}, src form.ValueSource) error {
+			return formgenBindSignup(f, o.(*Signup), src, "")
+		},
+		Check: func(f *form.Form, o interface{}, src form.ValueSource, errs *form.ValidationErrors) error {
+			return formgenCheckSignup(f, o.(*Signup), src, "", errs)
+		},
+		Hash: "5e358e0e58f45a4f",
+	})
+}
+
+func formgenBindBase(f *form.Form, o *Base, src form.ValueSource, prefix string) error {
+	{
+		key := f.GenKey(prefix, "id")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			n, err := form.GenInt(input, 64, false)
+			if err != nil {
+				return f.GenError(src, "ID", key, "ID", input, "", err)
+			}
+			o.ID = int64(n)
+		}
+	}
+	{
+		key := f.GenKey(prefix, "version")
+		input := f.GenInput(src, key, "", false)
+		if input == "" {
+			input = "1"
+		}
+		if input != "" {
+			n, err := form.GenUint(input, 16, false)
+			if err != nil {
+				return f.GenError(src, "Version", key, "Version", input, "", err)
+			}
+			o.Version = uint16(n)
+		}
+	}
+	return nil
+}
+
+func formgenBindextra(f *form.Form, o *extra, src form.ValueSource, prefix string) error {
+	{
+		key := f.GenKey(prefix, "note")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			o.Note = input
+		}
+	}
+	{
+		key := f.GenKey(prefix, "level")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			n, err := form.GenInt(input, 0, false)
+			if err != nil {
+				return f.GenError(src, "Level", key, "等级", input, "", err)
+			}
+			o.Level = int(n)
+		}
+	}
+	return nil
+}
+
+func formgenBindAddress(f *form.Form, o *Address, src form.ValueSource, prefix string) error {
+	{
+		key := f.GenKey(prefix, "city")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			o.City = input
+		}
+	}
+	{
+		key := f.GenKey(prefix, "street")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			o.Street = input
+		}
+	}
+	return nil
+}
+
+func formgenCheckAddress(f *form.Form, o *Address, src form.ValueSource, prefix string, errs *form.ValidationErrors) error {
+	{
+		key := f.GenKey(prefix, "city")
+		c := f.GenField(src, errs, prefix, "City", key, "城市", f.GenInput(src, key, "", false), "")
+		if err := c.Required("required", ""); err != nil {
+			return err
+		}
+		if err := c.Limit(&formgenLimits[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BindOrder 绑定Order，与form.Form.Bind相同，f为nil时使用form.Default()
+func BindOrder(f *form.Form, o *Order, src form.ValueSource) error {
+	if f == nil {
+		f = form.Default()
+	}
+	return formgenBindOrder(f, o, src, "")
+}
+
+// CheckOrder 校验Order，与form.Form.Check相同，f为nil时使用form.Default()
+func CheckOrder(f *form.Form, o *Order, src form.ValueSource) error {
+	if f == nil {
+		f = form.Default()
+	}
+	return f.CheckSource(o, src)
+}
+
+func formgenBindOrder(f *form.Form, o *Order, src form.ValueSource, prefix string) error {
+	if err := formgenBindBase(f, &o.Base, src, prefix); err != nil {
+		return err
+	}
+	if err := formgenBindextra(f, &o.extra, src, prefix); err != nil {
+		return err
+	}
+	{
+		key := f.GenKey(prefix, "username")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			o.UserName = input
+		}
+	}
+	{
+		key := f.GenKey(prefix, "email")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			o.Email = input
+		}
+	}
+	{
+		key := f.GenKey(prefix, "age")
+		input := f.GenInput(src, key, "", false)
+		if input == "" {
+			input = "18"
+		}
+		if input != "" {
+			n, err := form.GenInt(input, 8, false)
+			if err != nil {
+				return f.GenError(src, "Age", key, "年龄", input, "", err)
+			}
+			o.Age = int8(n)
+		}
+	}
+	{
+		key := f.GenKey(prefix, "count")
+		input := f.GenInput(src, key, "", true)
+		if input != "" {
+			n, err := form.GenInt(input, 0, false)
+			if err != nil {
+				return f.GenError(src, "Count", key, "数量", input, "", err)
+			}
+			o.Count = int(n)
+		}
+	}
+	{
+		key := f.GenKey(prefix, "weight")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			n, err := form.GenFloat(input, 32)
+			if err != nil {
+				return f.GenError(src, "Weight", key, "重量", input, "", err)
+			}
+			o.Weight = float32(n)
+		}
+	}
+	{
+		key := f.GenKey(prefix, "paid")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			if input != "false" && input != "0" {
+				o.Paid = true
+			}
+		}
+	}
+	{
+		key := f.GenKey(prefix, "notify")
+		input := f.GenInput(src, key, "", false)
+		if input == "" && f.GenHas(src, key, "") {
+			o.Notify = new(bool)
+		} else {
+			if input != "" {
+				value := input != "false" && input != "0"
+				o.Notify = &value
+			}
+		}
+	}
+	{
+		key := f.GenKey(prefix, "coupon")
+		input := f.GenInput(src, key, "", false)
+		if input == "" && f.GenHas(src, key, "") {
+			o.Coupon = new(string)
+		} else {
+			if input != "" {
+				value := input
+				o.Coupon = &value
+			}
+		}
+	}
+	{
+		key := f.GenKey(prefix, "tag")
+		inputs := f.GenInputs(src, key, "", false, "")
+		if len(inputs) > 0 {
+			values := make([]string, 0, len(inputs))
+			for _, input := range inputs {
+				values = append(values, input)
+			}
+			o.Tags = values
+		}
+	}
+	{
+		key := f.GenKey(prefix, "ids")
+		inputs := f.GenInputs(src, key, "", false, ",")
+		if len(inputs) > 0 {
+			values := make([]uint, 0, len(inputs))
+			for _, input := range inputs {
+				n, err := form.GenUint(input, 0, false)
+				if err != nil {
+					return f.GenError(src, "IDs", key, "IDs", input, "", err)
+				}
+				values = append(values, uint(n))
+			}
+			o.IDs = values
+		}
+	}
+	{
+		key := f.GenKey(prefix, "quota")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			n, err := form.GenInt(input, 64, true)
+			if err != nil {
+				return f.GenError(src, "Quota", key, "配额", input, "", err)
+			}
+			o.Quota = int64(n)
+		}
+	}
+	{
+		key := f.GenKey(prefix, "timeout")
+		input := f.GenInput(src, key, "", false)
+		if input == "" {
+			input = "30s"
+		}
+		if input != "" {
+			n, err := form.GenDuration(input)
+			if err != nil {
+				return f.GenError(src, "Timeout", key, "超时", input, "", err)
+			}
+			o.Timeout = n
+		}
+	}
+	{
+		key := f.GenKey(prefix, "created")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			n, err := f.GenTime(input, "", "")
+			if err != nil {
+				return f.GenError(src, "Created", key, "创建时间", input, "", err)
+			}
+			o.Created = n
+		}
+	}
+	{
+		key := f.GenKey(prefix, "expire")
+		input := f.GenInput(src, key, "", false)
+		if input == "" && f.GenHas(src, key, "") {
+			o.Expire = new(time.Time)
+		} else {
+			if input != "" {
+				n, err := f.GenTime(input, "", "ms")
+				if err != nil {
+					return f.GenError(src, "Expire", key, "Expire", input, "", err)
+				}
+				value := n
+				o.Expire = &value
+			}
+		}
+	}
+	{
+		key := f.GenKey(prefix, "date")
+		inputs := f.GenInputs(src, key, "", false, "")
+		if len(inputs) > 0 {
+			values := make([]time.Time, 0, len(inputs))
+			for _, input := range inputs {
+				n, err := f.GenTime(input, "2006/01/02", "")
+				if err != nil {
+					return f.GenError(src, "Dates", key, "Dates", input, "", err)
+				}
+				values = append(values, n)
+			}
+			o.Dates = values
+		}
+	}
+	{
+		key := f.GenKey(prefix, "X-Tenant")
+		input := f.GenInput(src, key, "header", false)
+		if input != "" {
+			o.Tenant = input
+		}
+	}
+	{
+		key := f.GenKey(prefix, "confirm")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			o.Confirm = input
+		}
+	}
+	if err := formgenBindAddress(f, &o.Shipping, src, f.GenKey(prefix, "shipping")); err != nil {
+		return err
+	}
+	if err := formgenBindAddress(f, &o.Billing, src, f.GenKey(prefix, "billing")); err != nil {
+		return err
+	}
+	{
+		key := f.GenKey(prefix, "retry")
+		inputs := f.GenInputs(src, key, "", false, "")
+		if len(inputs) > 0 {
+			values := make([]time.Duration, 0, len(inputs))
+			for _, input := range inputs {
+				n, err := form.GenDuration(input)
+				if err != nil {
+					return f.GenError(src, "Retry", key, "Retry", input, "", err)
+				}
+				values = append(values, n)
+			}
+			o.Retry = values
+		}
+	}
+	return nil
+}
+
+func formgenCheckOrder(f *form.Form, o *Order, src form.ValueSource, prefix string, errs *form.ValidationErrors) error {
+	{
+		key := f.GenKey(prefix, "id")
+		c := f.GenField(src, errs, prefix, "ID", key, "ID", f.GenInput(src, key, "", false), "")
+		if err := c.Required("required", ""); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "level")
+		c := f.GenField(src, errs, prefix, "Level", key, "等级", f.GenInput(src, key, "", false), "")
+		if err := c.Limit(&formgenLimits[1]); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "username")
+		c := f.GenField(src, errs, prefix, "UserName", key, "用户名", f.GenInput(src, key, "", false), "required=请输入用户名")
+		if err := c.Required("required", ""); err != nil {
+			return err
+		}
+		if err := c.Rule("username", "", form.UserName); err != nil {
+			return err
+		}
+		if err := c.Limit(&formgenLimits[2]); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "email")
+		c := f.GenField(src, errs, prefix, "Email", key, "邮箱", f.GenInput(src, key, "", false), "")
+		if err := c.Rule("email", "", form.Email); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "age")
+		c := f.GenField(src, errs, prefix, "Age", key, "年龄", f.GenInput(src, key, "", false), "")
+		if err := c.Limit(&formgenLimits[3]); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "count")
+		c := f.GenField(src, errs, prefix, "Count", key, "数量", f.GenInput(src, key, "", true), "")
+		if err := c.Limit(&formgenLimits[4]); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "weight")
+		c := f.GenField(src, errs, prefix, "Weight", key, "重量", f.GenInput(src, key, "", false), "")
+		if err := c.Limit(&formgenLimits[5]); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "tag")
+		c := f.GenSliceField(src, errs, prefix, "Tags", key, "标签", f.GenInputs(src, key, "", false, ""), "")
+		if err := c.Limit(&formgenLimits[6]); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "quota")
+		c := f.GenField(src, errs, prefix, "Quota", key, "配额", f.GenInput(src, key, "", false), "")
+		if err := c.Limit(&formgenLimits[7]); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "confirm")
+		c := f.GenField(src, errs, prefix, "Confirm", key, "确认邮箱", f.GenInput(src, key, "", false), "")
+		if err := c.CompareString("eqfield", "Email", "邮箱", f.GenInput(src, f.GenKey(prefix, "email"), "", false)); err != nil {
+			return err
+		}
+	}
+	if err := formgenCheckAddress(f, &o.Shipping, src, f.GenKey(prefix, "shipping"), errs); err != nil {
+		return err
+	}
+	if err := formgenCheckAddress(f, &o.Billing, src, f.GenKey(prefix, "billing"), errs); err != nil {
+		return err
+	}
+	return nil
+}
+
+// BindSignup 绑定Signup，与form.Form.Bind相同，f为nil时使用form.Default()
+func BindSignup(f *form.Form, o *Signup, src form.ValueSource) error {
+	if f == nil {
+		f = form.Default()
+	}
+	return formgenBindSignup(f, o, src, "")
+}
+
+// CheckSignup 校验Signup，与form.Form.Check相同，f为nil时使用form.Default()
+func CheckSignup(f *form.Form, o *Signup, src form.ValueSource) error {
+	if f == nil {
+		f = form.Default()
+	}
+	return f.CheckSource(o, src)
+}
+
+func formgenBindSignup(f *form.Form, o *Signup, src form.ValueSource, prefix string) error {
+	if err := formgenBindBase(f, &o.Base, src, prefix); err != nil {
+		return err
+	}
+	{
+		key := f.GenKey(prefix, "mobile")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			o.Mobile = input
+		}
+	}
+	{
+		key := f.GenKey(prefix, "email")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			o.Email = input
+		}
+	}
+	{
+		key := f.GenKey(prefix, "method")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			o.Method = input
+		}
+	}
+	{
+		key := f.GenKey(prefix, "contact")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			o.Contact = input
+		}
+	}
+	{
+		key := f.GenKey(prefix, "referer")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			n, err := form.GenInt(input, 64, false)
+			if err != nil {
+				return f.GenError(src, "Referer", key, "推荐人", input, "", err)
+			}
+			o.Referer = int64(n)
+		}
+	}
+	{
+		key := f.GenKey(prefix, "min")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			n, err := form.GenUint(input, 0, false)
+			if err != nil {
+				return f.GenError(src, "Min", key, "最小值", input, "", err)
+			}
+			o.Min = uint(n)
+		}
+	}
+	{
+		key := f.GenKey(prefix, "max")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			n, err := form.GenUint(input, 0, false)
+			if err != nil {
+				return f.GenError(src, "Max", key, "最大值", input, "", err)
+			}
+			o.Max = uint(n)
+		}
+	}
+	{
+		key := f.GenKey(prefix, "price")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			n, err := form.GenFloat(input, 64)
+			if err != nil {
+				return f.GenError(src, "Price", key, "价格", input, "", err)
+			}
+			o.Price = float64(n)
+		}
+	}
+	{
+		key := f.GenKey(prefix, "start")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			n, err := f.GenTime(input, "", "")
+			if err != nil {
+				return f.GenError(src, "Start", key, "开始时间", input, "", err)
+			}
+			o.Start = n
+		}
+	}
+	{
+		key := f.GenKey(prefix, "end")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			n, err := f.GenTime(input, "", "")
+			if err != nil {
+				return f.GenError(src, "End", key, "结束时间", input, "", err)
+			}
+			o.End = n
+		}
+	}
+	{
+		key := f.GenKey(prefix, "retry")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			n, err := form.GenDuration(input)
+			if err != nil {
+				return f.GenError(src, "Retry", key, "重试间隔", input, "", err)
+			}
+			o.Retry = n
+		}
+	}
+	{
+		key := f.GenKey(prefix, "timeout")
+		input := f.GenInput(src, key, "", false)
+		if input == "" {
+			input = "30s"
+		}
+		if input != "" {
+			n, err := form.GenDuration(input)
+			if err != nil {
+				return f.GenError(src, "Timeout", key, "超时", input, "", err)
+			}
+			o.Timeout = n
+		}
+	}
+	{
+		key := f.GenKey(prefix, "invite")
+		input := f.GenInput(src, key, "", false)
+		if input != "" {
+			o.Invite = input
+		}
+	}
+	return nil
+}
+
+func formgenCheckSignup(f *form.Form, o *Signup, src form.ValueSource, prefix string, errs *form.ValidationErrors) error {
+	{
+		key := f.GenKey(prefix, "id")
+		c := f.GenField(src, errs, prefix, "ID", key, "ID", f.GenInput(src, key, "", false), "")
+		if err := c.Required("required", ""); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "mobile")
+		c := f.GenField(src, errs, prefix, "Mobile", key, "手机号", f.GenInput(src, key, "", false), "")
+		if err := c.Rule("mobile", "", form.Mobile); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "email")
+		c := f.GenField(src, errs, prefix, "Email", key, "邮箱", f.GenInput(src, key, "", false), "")
+		if f.GenInput(src, f.GenKey(prefix, "mobile"), "", false) == "" {
+			if err := c.Required("required_without", "Mobile"); err != nil {
+				return err
+			}
+		}
+		if err := c.Rule("email", "", form.Email); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "contact")
+		c := f.GenField(src, errs, prefix, "Contact", key, "联系人", f.GenInput(src, key, "", false), "")
+		if other := f.GenInput(src, f.GenKey(prefix, "method"), "", false); other == "phone" || other == "sms" {
+			if err := c.Required("required_if", "Method,phone,sms"); err != nil {
+				return err
+			}
+		}
+	}
+	{
+		key := f.GenKey(prefix, "referer")
+		c := f.GenField(src, errs, prefix, "Referer", key, "推荐人", f.GenInput(src, key, "", false), "")
+		if err := c.CompareInt("nefield", "ID", "ID", f.GenInput(src, f.GenKey(prefix, "id"), "", false),
+			func(s string) (int64, error) { return form.GenInt(s, 64, false) },
+			func(s string) (int64, error) { return form.GenInt(s, 64, false) },
+		); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "max")
+		c := f.GenField(src, errs, prefix, "Max", key, "最大值", f.GenInput(src, key, "", false), "")
+		if err := c.CompareUint("gtefield", "Min", "最小值", f.GenInput(src, f.GenKey(prefix, "min"), "", false),
+			func(s string) (uint64, error) { return form.GenUint(s, 0, false) },
+			func(s string) (uint64, error) { return form.GenUint(s, 0, false) },
+		); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "price")
+		c := f.GenField(src, errs, prefix, "Price", key, "价格", f.GenInput(src, key, "", false), "")
+		if err := c.Limit(&formgenLimits[8]); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "end")
+		c := f.GenField(src, errs, prefix, "End", key, "结束时间", f.GenInput(src, key, "", false), "")
+		if err := c.CompareTime("gtfield", "Start", "开始时间", f.GenInput(src, f.GenKey(prefix, "start"), "", false),
+			func(s string) (time.Time, error) { return f.GenTime(s, "", "") },
+			func(s string) (time.Time, error) { return f.GenTime(s, "", "") },
+		); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "retry")
+		c := f.GenField(src, errs, prefix, "Retry", key, "重试间隔", f.GenInput(src, key, "", false), "")
+		if err := c.CompareInt("ltefield", "Timeout", "超时", f.GenInput(src, f.GenKey(prefix, "timeout"), "", false),
+			func(s string) (int64, error) {
+				d, err := form.GenDuration(s)
+				return int64(d), err
+			},
+			func(s string) (int64, error) {
+				d, err := form.GenDuration(s)
+				return int64(d), err
+			},
+		); err != nil {
+			return err
+		}
+	}
+	{
+		key := f.GenKey(prefix, "invite")
+		c := f.GenField(src, errs, prefix, "Invite", key, "邀请码", f.GenInput(src, key, "", false), "")
+		if err := c.Custom("invite", "", o, o, "Invite"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formgenLimits 生成时按字段类型解析好的min、max、range规则
+var formgenLimits = [...]form.GenLimit{
+	// Address.City max:32
+	{Rule: "max", Params: "32", Kind: "len", Max: &form.GenBound{Int: 32, Text: "32"}},
+	// extra.Level max:9
+	{Rule: "max", Params: "9", Kind: "int", Unit: "integer", Max: &form.GenBound{Int: 9, Text: "9"}},
+	// Order.UserName range:4,16
+	{Rule: "range", Params: "4,16", Kind: "len", Min: &form.GenBound{Int: 4, Text: "4"}, Max: &form.GenBound{Int: 16, Text: "16"}},
+	// Order.Age range:18,120
+	{Rule: "range", Params: "18,120", Kind: "int", Unit: "integer", Min: &form.GenBound{Int: 18, Text: "18"}, Max: &form.GenBound{Int: 120, Text: "120"}},
+	// Order.Count min:1
+	{Rule: "min", Params: "1", Kind: "int", Unit: "integer", Min: &form.GenBound{Int: 1, Text: "1"}},
+	// Order.Weight max:300
+	{Rule: "max", Params: "300", Kind: "float", Max: &form.GenBound{Float: 300, Text: "300.000000"}},
+	// Order.Tags max:3
+	{Rule: "max", Params: "3", Kind: "count", Max: &form.GenBound{Int: 3, Text: "3"}},
+	// Order.Quota max:100MB
+	{Rule: "max", Params: "100MB", Kind: "int", Unit: "size", Max: &form.GenBound{Int: 104857600, Text: "100MB"}},
+	// Signup.Price range:0.01,99.99
+	{Rule: "range", Params: "0.01,99.99", Kind: "float", Min: &form.GenBound{Float: 0.01, Text: "0.010000"}, Max: &form.GenBound{Float: 99.99, Text: "99.990000"}},
+}
@@ -0,0 +1,315 @@
+package gentest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	form "github.com/jiazhoulvke/echo-form"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func goodValues() url.Values {
+	return url.Values{
+		"id":              []string{"7"},
+		"username":        []string{"jiazhoulvke"},
+		"email":           []string{"a@example.com"},
+		"confirm":         []string{"a@example.com"},
+		"count":           []string{`"3"`},
+		"weight":          []string{"60.5"},
+		"paid":            []string{"1"},
+		"notify":          []string{"false"},
+		"coupon":          []string{""},
+		"tag":             []string{"a", "b"},
+		"ids":             []string{"1,2", "3"},
+		"quota":           []string{"10MB"},
+		"created":         []string{"2020-03-05 06:07:08"},
+		"expire":          []string{"1577894400123"},
+		"date":            []string{"2020/01/02", "2020/01/03"},
+		"retry":           []string{"1s", "2m"},
+		"shipping.city":   []string{"Shanghai"},
+		"billing.city":    []string{"Beijing"},
+		"billing.street":  []string{"Chang'an"},
+		"internal":        []string{"x"},
+		"Secret":          []string{"s"},
+		"shipping.street": []string{"Nanjing Rd"},
+		"note":            []string{"fragile"},
+		"level":           []string{"3"},
+	}
+}
+
+func newRequest(values url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Tenant", "acme")
+	return req
+}
+
+var reflectForm = form.New(func(f *form.Form) {
+	f.NoGenerated = true
+})
+
+func TestGenerated(t *testing.T) {
+	Convey("测试生成的代码", t, func() {
+		Convey("绑定", func() {
+			var g, r Order
+			So(form.BindSource(form.RequestSource(newRequest(goodValues())), &g), ShouldBeNil)
+			So(reflectForm.BindSource(&r, form.RequestSource(newRequest(goodValues()))), ShouldBeNil)
+			So(g, ShouldResemble, r)
+			So(g.ID, ShouldEqual, 7)
+			So(g.Version, ShouldEqual, 1)
+			So(g.Count, ShouldEqual, 3)
+			So(*g.Notify, ShouldBeFalse)
+			So(*g.Coupon, ShouldEqual, "")
+			So(g.IDs, ShouldResemble, []uint{1, 2, 3})
+			So(g.Quota, ShouldEqual, 10<<20)
+			So(g.Tenant, ShouldEqual, "acme")
+			So(g.Secret, ShouldEqual, "")
+			So(g.Billing.Street, ShouldEqual, "Chang'an")
+			So(g.Note, ShouldEqual, "fragile")
+			So(g.Level, ShouldEqual, 3)
+
+			var d Order
+			So(BindOrder(nil, &d, form.RequestSource(newRequest(goodValues()))), ShouldBeNil)
+			So(d, ShouldResemble, g)
+
+			values := goodValues()
+			values.Del("coupon")
+			g, r = Order{}, Order{}
+			So(form.BindSource(form.ValuesSource(values), &g), ShouldBeNil)
+			So(reflectForm.BindSource(&r, form.ValuesSource(values)), ShouldBeNil)
+			So(g, ShouldResemble, r)
+			So(g.Coupon, ShouldBeNil)
+		})
+
+		Convey("校验", func() {
+			var o Order
+			src := form.RequestSource(newRequest(goodValues()))
+			So(form.BindSource(src, &o), ShouldBeNil)
+			So(form.CheckSource(src, &o), ShouldBeNil)
+			So(CheckOrder(nil, &o, src), ShouldBeNil)
+		})
+
+		Convey("错误与反射一致", func() {
+			cases := []url.Values{
+				{"age": []string{"300"}},
+				{"age": []string{"abc"}},
+				{"weight": []string{"1e40"}},
+				{"quota": []string{"10XB"}},
+				{"timeout": []string{"abc"}},
+				{"created": []string{"2020-13-45"}},
+				{"ids": []string{"1,-2"}},
+				{"date": []string{"2020-01-02"}},
+				{"version": []string{"70000"}},
+				{"level": []string{"abc"}},
+			}
+			for _, c := range cases {
+				var g, r Order
+				gerr := form.BindSource(form.ValuesSource(c), &g)
+				rerr := reflectForm.BindSource(&r, form.ValuesSource(c))
+				So(gerr, ShouldNotBeNil)
+				So(gerr, ShouldResemble, rerr)
+			}
+
+			values := goodValues()
+			values.Set("username", "ab")
+			values.Set("confirm", "b@example.com")
+			values.Set("age", "12")
+			values.Del("shipping.city")
+			values.Del("id")
+			var o Order
+			src := form.ValuesSource(values)
+			So(form.BindSource(src, &o), ShouldBeNil)
+			gerr := form.CheckAllSource(src, &o)
+			rerr := reflectForm.CheckAllSource(&o, src)
+			So(gerr, ShouldNotBeNil)
+			So(gerr, ShouldResemble, rerr)
+			So(gerr.Error(), ShouldEqual, "ID不能为空;用户名的长度不能小于4;年龄不能小于18;确认邮箱必须与邮箱相同;城市不能为空")
+
+			values.Del("username")
+			gerr = CheckOrder(nil, &o, form.ValuesSource(values))
+			So(gerr.Error(), ShouldEqual, "ID不能为空")
+			So(form.CheckSource(form.ValuesSource(url.Values{"id": []string{"1"}}), &o).Error(), ShouldEqual, "请输入用户名")
+		})
+	})
+}
+
+func init() {
+	form.AddCheckFunc("invite", func(c form.Context) error {
+		if c.Input != "" && c.Input == c.Parent.FieldByName("Mobile").String() {
+			return fmt.Errorf("%s不能是自己的手机号", c.Title)
+		}
+		return nil
+	})
+}
+
+func goodSignup() url.Values {
+	return url.Values{
+		"id":      []string{"7"},
+		"mobile":  []string{"13800138000"},
+		"method":  []string{"phone"},
+		"contact": []string{"张三"},
+		"referer": []string{"8"},
+		"min":     []string{"1"},
+		"max":     []string{"1"},
+		"price":   []string{"9.9"},
+		"start":   []string{"2020-03-05 06:07:08"},
+		"end":     []string{"2020-03-06 06:07:08"},
+		"retry":   []string{"10s"},
+		"timeout": []string{"30s"},
+		"invite":  []string{"abc"},
+	}
+}
+
+func TestGeneratedRules(t *testing.T) {
+	Convey("测试生成的校验规则与反射一致", t, func() {
+		check := func(values url.Values) (error, error) {
+			var o Signup
+			src := form.ValuesSource(values)
+			So(form.BindSource(src, &o), ShouldBeNil)
+			return form.CheckAllSource(src, &o), reflectForm.CheckAllSource(&o, src)
+		}
+		gerr, rerr := check(goodSignup())
+		So(gerr, ShouldBeNil)
+		So(rerr, ShouldBeNil)
+
+		cases := []struct {
+			set url.Values
+			del []string
+			msg string
+		}{
+			{set: url.Values{"mobile": []string{"123"}}, msg: "手机号必须为正确的手机号码"},
+			{del: []string{"mobile"}, msg: "邮箱不能为空"},
+			{set: url.Values{"mobile": []string{""}, "email": []string{"abc"}}, msg: "邮箱不是正确email格式"},
+			{set: url.Values{"method": []string{"sms"}}, del: []string{"contact"}, msg: "联系人不能为空"},
+			{set: url.Values{"referer": []string{"7"}}, msg: "推荐人不能与ID相同"},
+			{set: url.Values{"min": []string{"2"}}, msg: "最大值不能小于最小值"},
+			{set: url.Values{"price": []string{"100"}}, msg: "价格不能大于99.990000"},
+			{set: url.Values{"end": []string{"2020-03-05 06:07:08"}}, msg: "结束时间必须大于开始时间"},
+			{set: url.Values{"retry": []string{"1m"}}, msg: "重试间隔不能大于超时"},
+			{set: url.Values{"invite": []string{"13800138000"}}, msg: "邀请码不能是自己的手机号"},
+			{del: []string{"timeout"}, msg: "重试间隔不能大于超时"}, //与反射一致，比较的是输入，不使用默认值
+		}
+		for _, c := range cases {
+			values := goodSignup()
+			for k, v := range c.set {
+				values[k] = v
+			}
+			for _, k := range c.del {
+				values.Del(k)
+			}
+			gerr, rerr := check(values)
+			So(gerr, ShouldNotBeNil)
+			So(gerr, ShouldResemble, rerr)
+			So(gerr.Error(), ShouldEqual, c.msg)
+		}
+
+		values := goodSignup()
+		values.Set("method", "mail")
+		values.Del("contact")
+		gerr, rerr = check(values)
+		So(gerr, ShouldBeNil) //required_if的条件不满足
+		So(rerr, ShouldBeNil)
+
+		f := form.New()
+		f.RegisterCheck("mobile", func(form.Context) error { return nil })
+		values = goodSignup()
+		values.Set("mobile", "123")
+		var o Signup
+		So(f.BindSource(&o, form.ValuesSource(values)), ShouldBeNil)
+		So(f.CheckSource(&o, form.ValuesSource(values)), ShouldBeNil) //覆盖了内置的检测器，使用反射校验
+		So(form.CheckSource(form.ValuesSource(values), &o), ShouldNotBeNil)
+	})
+}
+
+//stale 模拟修改了tag之后没有重新生成的struct
+type stale struct {
+	Name string `form:"name" valid:"required"`
+}
+
+func TestGeneratedHash(t *testing.T) {
+	Convey("测试生成代码的摘要", t, func() {
+		So(form.Validate(Order{}, Signup{}), ShouldBeNil) //生成的代码与struct一致
+
+		fail := func(f *form.Form, o interface{}, src form.ValueSource) error {
+			panic("不应该使用过期的生成代码")
+		}
+		form.RegisterGenerated((*stale)(nil), form.Generated{
+			Bind: fail,
+			Check: func(f *form.Form, o interface{}, src form.ValueSource, errs *form.ValidationErrors) error {
+				return fail(f, o, src)
+			},
+			Hash: form.GenHash(`{Name string "form:\"title\" valid:\"required\"";}`),
+		})
+		var o stale
+		src := form.ValuesSource(url.Values{"name": []string{"x"}})
+		So(form.BindSource(src, &o), ShouldBeNil) //使用反射
+		So(o.Name, ShouldEqual, "x")
+		So(form.CheckSource(src, &o), ShouldBeNil)
+		err := form.Validate(stale{})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "gentest.stale的生成代码已过期，需要重新运行echo-formgen")
+
+		form.RegisterGenerated((*stale)(nil), form.Generated{
+			Bind: func(f *form.Form, o interface{}, src form.ValueSource) error {
+				o.(*stale).Name = "generated"
+				return nil
+			},
+			Hash: form.GenHash(`{Name string "form:\"name\" valid:\"required\"";}`),
+		})
+		So(form.BindSource(src, &o), ShouldBeNil)
+		So(o.Name, ShouldEqual, "generated")
+		So(form.Validate(stale{}), ShouldBeNil)
+	})
+}
+
+func BenchmarkGeneratedBind(b *testing.B) {
+	src := form.ValuesSource(goodValues())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var o Order
+		if err := form.BindSource(src, &o); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReflectBind(b *testing.B) {
+	src := form.ValuesSource(goodValues())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var o Order
+		if err := reflectForm.BindSource(&o, src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGeneratedCheck(b *testing.B) {
+	src := form.ValuesSource(goodValues())
+	var o Order
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := form.CheckSource(src, &o); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReflectCheck(b *testing.B) {
+	src := form.ValuesSource(goodValues())
+	var o Order
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := reflectForm.CheckSource(&o, src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+//Package gentest 用于测试echo-formgen生成的代码与反射绑定、校验的结果一致
+package gentest
+
+//go:generate go run ../../cmd/echo-formgen
+
+import "time"
+
+//Base 匿名嵌入的struct
+type Base struct {
+	ID      int64  `form:"id" title:"ID" valid:"required"`
+	Version uint16 `form:"version" default:"1"`
+}
+
+//Address 嵌套的struct
+type Address struct {
+	City   string `form:"city" title:"城市" valid:"required;max:32"`
+	Street string `form:"street" title:"街道"`
+}
+
+//extra 不可导出的匿名嵌入struct，其中可导出的字段仍然绑定
+type extra struct {
+	Note  string `form:"note"`
+	Level int    `form:"level" title:"等级" valid:"max:9"`
+}
+
+//Order 订单
+//echo-form:generate
+type Order struct {
+	Base
+	extra
+	UserName string        `form:"username" title:"用户名" valid:"required;username;range:4,16" msg:"required=请输入用户名"`
+	Email    string        `json:"email,omitempty" title:"邮箱" valid:"email"`
+	Age      int8          `form:"age" title:"年龄" valid:"range:18,120" default:"18"`
+	Count    int           `json:"count,string" title:"数量" valid:"min:1"`
+	Weight   float32       `form:"weight" title:"重量" valid:"max:300"`
+	Paid     bool          `form:"paid"`
+	Notify   *bool         `form:"notify"`
+	Coupon   *string       `form:"coupon"`
+	Tags     []string      `form:"tag" title:"标签" valid:"max:3"`
+	IDs      []uint        `form:"ids" sep:","`
+	Quota    int64         `form:"quota" title:"配额" unit:"bytes" valid:"max:100MB"`
+	Timeout  time.Duration `form:"timeout" title:"超时" default:"30s"`
+	Created  time.Time     `form:"created" title:"创建时间"`
+	Expire   *time.Time    `form:"expire" time_unit:"ms"`
+	Dates    []time.Time   `form:"date" time_format:"2006/01/02"`
+	Tenant   string        `form:"X-Tenant" from:"header"`
+	Secret   string        `form:"-"`
+	Confirm  string        `form:"confirm" title:"确认邮箱" valid:"eqfield:Email"`
+	Shipping Address       `form:"shipping"`
+	Billing  Address       `form:"billing"`
+	internal string
+	Retry    []time.Duration `form:"retry"`
+}
+
+//Signup 注册，覆盖比较、required_if等引用同级字段的规则和自定义的检测器
+//echo-form:generate
+type Signup struct {
+	Base
+	Mobile  string        `form:"mobile" title:"手机号" valid:"mobile"`
+	Email   string        `form:"email" title:"邮箱" valid:"required_without:Mobile;email"`
+	Method  string        `form:"method"`
+	Contact string        `form:"contact" title:"联系人" valid:"required_if:Method,phone,sms"`
+	Referer int64         `form:"referer" title:"推荐人" valid:"nefield:ID"`
+	Min     uint          `form:"min" title:"最小值"`
+	Max     uint          `form:"max" title:"最大值" valid:"gtefield:Min"`
+	Price   float64       `form:"price" title:"价格" valid:"range:0.01,99.99"`
+	Start   time.Time     `form:"start" title:"开始时间"`
+	End     time.Time     `form:"end" title:"结束时间" valid:"gtfield:Start"`
+	Retry   time.Duration `form:"retry" title:"重试间隔" valid:"ltefield:Timeout"`
+	Timeout time.Duration `form:"timeout" title:"超时" default:"30s"`
+	Invite  string        `form:"invite" title:"邀请码" valid:"invite"`
+}
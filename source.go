@@ -0,0 +1,362 @@
+package form
+
+import (
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+//值的来源
+const (
+	//SourceForm 表单，包括请求体和query string
+	SourceForm = "form"
+	//SourceQuery query string
+	SourceQuery = "query"
+	//SourceParam 路由中的路径参数，如/users/:id
+	SourceParam = "param"
+	//SourceHeader 请求头
+	SourceHeader = "header"
+	//SourceCookie cookie
+	SourceCookie = "cookie"
+)
+
+//defaultMaxMemory 解析multipart请求时保存在内存中的最大字节数，与net/http一致
+const defaultMaxMemory = 32 << 20
+
+//ValueSource 绑定和校验时读取值的来源
+type ValueSource interface {
+	//Get 返回key对应的第一个值
+	Get(key string) string
+	//GetAll 返回key对应的所有值
+	GetAll(key string) []string
+	//Has key是否存在
+	Has(key string) bool
+}
+
+//SourceSelector 由多个来源组成的ValueSource，按from tag中的来源名返回对应的ValueSource，不支持的来源返回nil。
+//没有实现SourceSelector的ValueSource忽略from tag，所有字段都从它读取
+type SourceSelector interface {
+	Source(name string) ValueSource
+}
+
+//FileSource 可以提供上传文件的ValueSource
+type FileSource interface {
+	Files(key string) []*multipart.FileHeader
+}
+
+//EchoSource 读取echo.Context中的表单、query string、路由参数、请求头、cookie和上传文件，ctx为nil时返回nil
+func EchoSource(ctx echo.Context) ValueSource {
+	if ctx == nil {
+		return nil
+	}
+	return echoSource{ctx}
+}
+
+type echoSource struct {
+	ctx echo.Context
+}
+
+func (s echoSource) Get(key string) string {
+	return first(s.GetAll(key))
+}
+
+func (s echoSource) GetAll(key string) []string {
+	if params, err := s.ctx.FormParams(); err == nil {
+		return params[key]
+	}
+	if value := s.ctx.FormValue(key); value != "" {
+		return []string{value}
+	}
+	return nil
+}
+
+func (s echoSource) Has(key string) bool {
+	return len(s.GetAll(key)) > 0
+}
+
+func (s echoSource) Source(name string) ValueSource {
+	switch name {
+	case SourceForm:
+		return s
+	case SourceQuery:
+		return ValuesSource(s.ctx.QueryParams())
+	case SourceParam:
+		return paramSource{s.ctx}
+	case SourceHeader, SourceCookie:
+		if r := s.ctx.Request(); r != nil {
+			return RequestSource(r).(SourceSelector).Source(name)
+		}
+	}
+	return nil
+}
+
+func (s echoSource) Files(key string) []*multipart.FileHeader {
+	mf, err := s.ctx.MultipartForm()
+	if err != nil || mf == nil {
+		return nil
+	}
+	return mf.File[key]
+}
+
+type paramSource struct {
+	ctx echo.Context
+}
+
+func (s paramSource) Get(key string) string {
+	return s.ctx.Param(key)
+}
+
+func (s paramSource) GetAll(key string) []string {
+	if value := s.ctx.Param(key); value != "" {
+		return []string{value}
+	}
+	return nil
+}
+
+func (s paramSource) Has(key string) bool {
+	for _, name := range s.ctx.ParamNames() {
+		if name == key {
+			return true
+		}
+	}
+	return false
+}
+
+//RequestSource 读取*http.Request中的表单、query string、请求头、cookie和上传文件，r为nil时返回nil。
+//net/http没有路由参数，from:"param"的字段读取不到值
+func RequestSource(r *http.Request) ValueSource {
+	if r == nil {
+		return nil
+	}
+	return requestSource{r}
+}
+
+type requestSource struct {
+	r *http.Request
+}
+
+//form 解析并返回请求的表单，multipart请求同时解析上传文件
+func (s requestSource) form() url.Values {
+	if s.r.Form == nil {
+		if err := s.r.ParseMultipartForm(defaultMaxMemory); err != nil {
+			s.r.ParseForm()
+		}
+	}
+	return s.r.Form
+}
+
+func (s requestSource) Get(key string) string {
+	return first(s.GetAll(key))
+}
+
+func (s requestSource) GetAll(key string) []string {
+	return s.form()[key]
+}
+
+func (s requestSource) Has(key string) bool {
+	_, ok := s.form()[key]
+	return ok
+}
+
+func (s requestSource) Source(name string) ValueSource {
+	switch name {
+	case SourceForm:
+		return s
+	case SourceQuery:
+		if s.r.URL != nil {
+			return ValuesSource(s.r.URL.Query())
+		}
+	case SourceHeader:
+		return headerSource(s.r.Header)
+	case SourceCookie:
+		return cookieSource{s.r}
+	}
+	return nil
+}
+
+func (s requestSource) Files(key string) []*multipart.FileHeader {
+	s.form()
+	if s.r.MultipartForm == nil {
+		return nil
+	}
+	return s.r.MultipartForm.File[key]
+}
+
+type headerSource http.Header
+
+func (s headerSource) Get(key string) string {
+	return http.Header(s).Get(key)
+}
+
+func (s headerSource) GetAll(key string) []string {
+	return s[textproto.CanonicalMIMEHeaderKey(key)]
+}
+
+func (s headerSource) Has(key string) bool {
+	_, ok := s[textproto.CanonicalMIMEHeaderKey(key)]
+	return ok
+}
+
+type cookieSource struct {
+	r *http.Request
+}
+
+func (s cookieSource) Get(key string) string {
+	if cookie, err := s.r.Cookie(key); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+func (s cookieSource) GetAll(key string) []string {
+	var values []string
+	for _, cookie := range s.r.Cookies() {
+		if cookie.Name == key {
+			values = append(values, cookie.Value)
+		}
+	}
+	return values
+}
+
+func (s cookieSource) Has(key string) bool {
+	_, err := s.r.Cookie(key)
+	return err == nil
+}
+
+//ValuesSource 读取url.Values中的值，可以用于测试或者命令行工具
+func ValuesSource(values url.Values) ValueSource {
+	return valuesSource(values)
+}
+
+type valuesSource url.Values
+
+func (s valuesSource) Get(key string) string {
+	return url.Values(s).Get(key)
+}
+
+func (s valuesSource) GetAll(key string) []string {
+	return s[key]
+}
+
+func (s valuesSource) Has(key string) bool {
+	_, ok := s[key]
+	return ok
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+//sources 返回字段的来源，字段没有设置FromField时使用Form.Sources
+func (f *Form) sources(field reflect.StructField) []string {
+	if from := field.Tag.Get(f.FromField); from != "" {
+		return strings.Split(from, ",")
+	}
+	return f.Sources
+}
+
+//formValues 按字段的来源依次读取key对应的所有值，返回第一个有值的来源的结果，fm为nil时使用Form.Sources。
+//字段设置了string选项时去掉值两边的引号
+func (f *Form) formValues(fm *fieldMeta, key string, src ValueSource) []string {
+	values := f.sourceValues(fm, key, src)
+	if len(values) == 0 || fm == nil || !fm.quoted {
+		return values
+	}
+	unquoted := make([]string, len(values))
+	for i, value := range values {
+		unquoted[i] = value
+		if len(value) >= 2 && value[0] == '"' {
+			if s, err := strconv.Unquote(value); err == nil {
+				unquoted[i] = s
+			}
+		}
+	}
+	return unquoted
+}
+
+func (f *Form) sourceValues(fm *fieldMeta, key string, src ValueSource) []string {
+	if src == nil {
+		return nil
+	}
+	selector, ok := src.(SourceSelector)
+	if !ok {
+		return src.GetAll(key)
+	}
+	sources := f.Sources
+	if fm != nil {
+		sources = fm.sources
+	}
+	for _, name := range sources {
+		sub := selector.Source(strings.TrimSpace(name))
+		if sub == nil || !sub.Has(key) {
+			continue
+		}
+		if values := sub.GetAll(key); len(values) > 0 {
+			return values
+		}
+	}
+	return nil
+}
+
+//hasValue 按字段的来源判断请求中是否有key，值可以为空
+func (f *Form) hasValue(fm *fieldMeta, key string, src ValueSource) bool {
+	if src == nil {
+		return false
+	}
+	selector, ok := src.(SourceSelector)
+	if !ok {
+		return src.Has(key)
+	}
+	sources := f.Sources
+	if fm != nil {
+		sources = fm.sources
+	}
+	for _, name := range sources {
+		if sub := selector.Source(strings.TrimSpace(name)); sub != nil && sub.Has(key) {
+			return true
+		}
+	}
+	return false
+}
+
+//formValue 按字段的来源读取key对应的第一个值
+func (f *Form) formValue(fm *fieldMeta, key string, src ValueSource) string {
+	return first(f.formValues(fm, key, src))
+}
+
+//requestFiles 返回来源中key对应的上传文件，来源不支持上传文件时返回nil
+func requestFiles(src ValueSource, key string) []*multipart.FileHeader {
+	if fs, ok := src.(FileSource); ok {
+		return fs.Files(key)
+	}
+	return nil
+}
+
+//sourceHeader 返回来源中的请求头，来源没有请求头时返回空字符串
+func sourceHeader(src ValueSource, key string) string {
+	selector, ok := src.(SourceSelector)
+	if !ok {
+		return ""
+	}
+	if header := selector.Source(SourceHeader); header != nil {
+		return header.Get(key)
+	}
+	return ""
+}
+
+//echoContext 返回来源对应的echo.Context，不是EchoSource时返回nil
+func echoContext(src ValueSource) echo.Context {
+	if s, ok := src.(echoSource); ok {
+		return s.ctx
+	}
+	return nil
+}
@@ -1,9 +1,13 @@
 package form
 
 import (
+	"fmt"
+	"math"
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
 //IsRequired 是否有值
@@ -139,3 +143,177 @@ var idcartPattern = regexp.MustCompile(`(^\d{15}$)|(^\d{17}([0-9X])$)`)
 func IsIDCard(str string) bool {
 	return idcartPattern.MatchString(str)
 }
+
+//indirect 返回指针指向的值，nil指针返回对应类型的零值
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Zero(v.Type().Elem())
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+//fieldByIndex 返回struct v中的字段sf，经过nil的嵌入指针时返回字段类型的零值而不是panic
+func fieldByIndex(v reflect.Value, sf reflect.StructField) reflect.Value {
+	for i, x := range sf.Index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Zero(sf.Type)
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+//compareValues 比较a和b，a小于b时返回-1，相等时返回0，大于时返回1。
+//支持整数、浮点数、字符串、bool(只能判断是否相等)以及time.Time
+func compareValues(a, b reflect.Value) (int, error) {
+	a, b = indirect(a), indirect(b)
+	ka, kb := kindClass(a), kindClass(b)
+	if ka != kb {
+		return 0, fmt.Errorf("参数错误:无法比较%s和%s", a.Type(), b.Type())
+	}
+	switch ka {
+	case reflect.Int:
+		return compareInt(a.Int(), b.Int()), nil
+	case reflect.Uint:
+		return compareUint(a.Uint(), b.Uint()), nil
+	case reflect.Float64:
+		return compareFloat(a.Float(), b.Float()), nil
+	case reflect.String:
+		return strings.Compare(a.String(), b.String()), nil
+	case reflect.Bool:
+		if a.Bool() == b.Bool() {
+			return 0, nil
+		}
+		return 1, nil
+	case reflect.Struct:
+		if !a.CanInterface() || !b.CanInterface() {
+			break
+		}
+		return compareTime(a.Interface().(time.Time), b.Interface().(time.Time)), nil
+	}
+	return 0, fmt.Errorf("参数错误:不支持比较%s", a.Type())
+}
+
+func compareInt(x, y int64) int {
+	if x < y {
+		return -1
+	} else if x > y {
+		return 1
+	}
+	return 0
+}
+
+func compareUint(x, y uint64) int {
+	if x < y {
+		return -1
+	} else if x > y {
+		return 1
+	}
+	return 0
+}
+
+func compareFloat(x, y float64) int {
+	if x < y {
+		return -1
+	} else if x > y {
+		return 1
+	}
+	return 0
+}
+
+func compareTime(x, y time.Time) int {
+	if x.Before(y) {
+		return -1
+	} else if x.After(y) {
+		return 1
+	}
+	return 0
+}
+
+//kindClass 将同一类的Kind归为一种，time.Time归为reflect.Struct，其它不支持比较的类型返回reflect.Invalid
+func kindClass(v reflect.Value) reflect.Kind {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.Int
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return reflect.Uint
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	case reflect.String, reflect.Bool:
+		return v.Kind()
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return reflect.Struct
+		}
+	}
+	return reflect.Invalid
+}
+
+var sizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"K":   1 << 10,
+	"KB":  1 << 10,
+	"KIB": 1 << 10,
+	"M":   1 << 20,
+	"MB":  1 << 20,
+	"MIB": 1 << 20,
+	"G":   1 << 30,
+	"GB":  1 << 30,
+	"GIB": 1 << 30,
+	"T":   1 << 40,
+	"TB":  1 << 40,
+	"TIB": 1 << 40,
+}
+
+//parseSize 解析带单位的字节数，如10MB、1.5G、512，单位不区分大小写，1KB=1024B，不能为负数
+func parseSize(str string) (int64, error) {
+	str = strings.TrimSpace(str)
+	if strings.HasPrefix(str, "-") {
+		return 0, fmt.Errorf("大小不能为负数:%s", str)
+	}
+	i := strings.IndexFunc(str, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '+'
+	})
+	num, unit := str, ""
+	if i >= 0 {
+		num, unit = str[:i], strings.ToUpper(strings.TrimSpace(str[i:]))
+	}
+	multiple, ok := sizeUnits[unit]
+	if !ok || num == "" {
+		return 0, fmt.Errorf("错误的大小格式:%s", str)
+	}
+	if n, err := strconv.ParseInt(num, 10, 64); err == nil {
+		if n > math.MaxInt64/multiple {
+			return 0, fmt.Errorf("大小越界:%s", str)
+		}
+		return n * multiple, nil
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("错误的大小格式:%s", str)
+	}
+	n *= float64(multiple)
+	//float64(math.MaxInt64)为2^63，等于时也已经越界
+	if n >= math.MaxInt64 {
+		return 0, fmt.Errorf("大小越界:%s", str)
+	}
+	return int64(n), nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+//parseDuration 解析时长，如30s、1h30m，纯数字视为纳秒
+func parseDuration(str string) (int64, error) {
+	if n, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return n, nil
+	}
+	d, err := time.ParseDuration(str)
+	return int64(d), err
+}
@@ -0,0 +1,256 @@
+package form
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+//TagError struct tag中的错误
+type TagError struct {
+	//Type struct的类型名，参数不是struct时为参数的类型
+	Type string
+	//Field 字段名，嵌套struct中的字段以.连接，如Shipping.City。参数不是struct时为空
+	Field string
+	//Tag 出错的tag名，如valid、default
+	Tag string
+	//Err 具体的错误
+	Err error
+}
+
+func (e *TagError) Error() string {
+	if e.Field == "" {
+		//参数不是struct，没有字段
+		return fmt.Sprintf("%s%v", e.Type, e.Err)
+	}
+	return fmt.Sprintf("%s.%s的%s tag错误:%v", e.Type, e.Field, e.Tag, e.Err)
+}
+
+//Unwrap 返回具体的错误
+func (e *TagError) Unwrap() error {
+	return e.Err
+}
+
+//TagErrors Validate发现的所有tag错误
+type TagErrors []*TagError
+
+func (es TagErrors) Error() string {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+//Validate 使用默认的Form检查struct tag
+func Validate(types ...interface{}) error {
+	return form.Validate(types...)
+}
+
+//MustRegister 使用默认的Form检查struct tag，有错误时panic
+func MustRegister(types ...interface{}) {
+	form.MustRegister(types...)
+}
+
+//Validate 检查types中struct的valid和default tag，包括嵌套的struct：规则是否存在，参数个数和类型是否正确，
+//引用的字段是否存在，默认值能否转换为字段的类型。types为struct的值、指针或者reflect.Type。
+//用于在启动时发现拼写错误等问题，而不是等到请求时才报错。有错误时返回TagErrors，不是struct的参数以及
+//生成的代码已过期的类型也作为其中的一个错误
+func (f *Form) Validate(types ...interface{}) error {
+	var errs TagErrors
+	for _, o := range types {
+		t, ok := o.(reflect.Type)
+		if !ok {
+			t = reflect.TypeOf(o)
+		}
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			errs = append(errs, &TagError{Type: fmt.Sprint(t), Err: errors.New("不是struct")})
+			continue
+		}
+		f.validateStruct(t, t, t.String(), "", make(map[reflect.Type]bool), &errs)
+		if _, ok := staleGenerated.Load(t); ok {
+			errs = append(errs, &TagError{Type: t.String(), Err: errors.New("的生成代码已过期，需要重新运行echo-formgen")})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+//MustRegister 检查types的tag并缓存其字段信息，有错误时panic，一般在init或者main中调用
+func (f *Form) MustRegister(types ...interface{}) {
+	if err := f.Validate(types...); err != nil {
+		panic(err)
+	}
+}
+
+//validateStruct 检查struct t中的字段，parent为规则引用的同级字段所在的struct，匿名嵌入的struct与外层相同
+func (f *Form) validateStruct(t, parent reflect.Type, typeName, path string, seen map[reflect.Type]bool, errs *TagErrors) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	defer delete(seen, t)
+	hasField := func(name string) bool {
+		_, ok := parent.FieldByName(name)
+		return ok
+	}
+	for _, fm := range f.structMeta(t).fields {
+		field := fm.field
+		name := path + field.Name
+		switch fm.kind {
+		case nestedField, structPtrField:
+			elem, p := field.Type, parent
+			if fm.kind == structPtrField {
+				elem = elem.Elem()
+			}
+			if !field.Anonymous {
+				p = elem
+			}
+			f.validateStruct(elem, p, typeName, name+".", seen, errs)
+			continue
+		case structSliceField:
+			elem := field.Type.Elem()
+			f.validateStruct(elem, elem, typeName, name+".", seen, errs)
+		}
+		for _, e := range f.ValidateField(field, hasField) {
+			e.Type, e.Field = typeName, name
+			*errs = append(*errs, e)
+		}
+	}
+}
+
+//ValidateField 检查单个字段的valid和default tag，返回的TagError没有设置Type和Field。
+//hasField判断规则引用的同级字段是否存在，为nil时不检查。Validate和echoformvet都使用它检查字段
+func (f *Form) ValidateField(field reflect.StructField, hasField func(name string) bool) []*TagError {
+	var errs []*TagError
+	fm := f.newFieldMeta(field)
+	for _, r := range fm.rules {
+		if r.Name == "" {
+			continue
+		}
+		if err := f.ValidateRule(field, TagRule{Name: r.Name, Params: r.Params}, hasField); err != nil {
+			errs = append(errs, &TagError{Tag: f.ValidField, Err: err})
+		}
+	}
+	if err := f.validateDefault(fm); err != nil {
+		errs = append(errs, &TagError{Tag: f.DefaultField, Err: err})
+	}
+	return errs
+}
+
+//ValidateRule 检查字段的一个规则，错误信息以规则名开头。hasField与ValidateField相同
+//供echo-formgen等工具使用，随生成器变化，不保证兼容
+func (f *Form) ValidateRule(field reflect.StructField, r TagRule, hasField func(name string) bool) error {
+	if err := f.validateRule(field, rule{Name: r.Name, Params: r.Params}, hasField); err != nil {
+		return fmt.Errorf("%s:%v", r.Name, err)
+	}
+	return nil
+}
+
+//validateRule 检查规则是否存在，内置的规则还检查参数
+func (f *Form) validateRule(field reflect.StructField, r rule, hasField func(name string) bool) error {
+	c, ok := f.checkFunc(r.Name)
+	if !ok {
+		return fmt.Errorf("检测器%s找不到", r.Name)
+	}
+	if p, ok := builtinCheckers[r.Name]; !ok || reflect.ValueOf(c).Pointer() != p {
+		//自定义的检测器不知道参数的格式
+		return nil
+	}
+	if field.Type.Kind() == reflect.Ptr && !isFileType(field.Type) {
+		//与checkField一致，检测器看到的是指针指向的类型
+		field.Type = field.Type.Elem()
+	}
+	params := r.Params
+	switch r.Name {
+	case "min", "max":
+		if len(params) != 1 {
+			return fmt.Errorf("需要1个参数")
+		}
+		return f.validateLimit(field, params[0])
+	case "range":
+		if len(params) != 2 {
+			return fmt.Errorf("需要2个参数")
+		}
+		for _, param := range params {
+			if err := f.validateLimit(field, param); err != nil {
+				return err
+			}
+		}
+	case "required_if", "required_unless":
+		//引用的可以是字段名，也可以是请求中的key，因此不检查是否存在
+		if len(params) < 2 {
+			return fmt.Errorf("需要字段名和至少1个值")
+		}
+	case "required_with", "required_without":
+		if len(params) == 0 {
+			return fmt.Errorf("需要至少1个字段名")
+		}
+	case "eqfield", "nefield", "gtfield", "gtefield", "ltfield", "ltefield":
+		if len(params) != 1 {
+			return fmt.Errorf("需要1个字段名")
+		}
+		if hasField != nil && !hasField(params[0]) {
+			return fmt.Errorf("找不到字段%s", params[0])
+		}
+	case "filesize", "mimetype", "ext":
+		if !isFileType(field.Type) {
+			return fmt.Errorf("只能用于上传文件，字段的类型为%v", field.Type)
+		}
+		if len(params) == 0 || r.Name == "filesize" && len(params) != 1 {
+			return fmt.Errorf("参数个数错误")
+		}
+		if r.Name == "filesize" {
+			if _, err := parseSize(params[0]); err != nil {
+				return err
+			}
+		}
+	default:
+		if len(params) > 0 {
+			return fmt.Errorf("不需要参数")
+		}
+	}
+	return nil
+}
+
+//validateLimit 检查min、max、range的参数能否按字段的类型解析，与MinOrMax使用相同的parseLimit
+func (f *Form) validateLimit(field reflect.StructField, param string) error {
+	if !IsIntType(field) && !IsFloatType(field) && !IsStringType(field) && field.Type.Kind() != reflect.Slice {
+		return fmt.Errorf("未支持格式%v", field.Type)
+	}
+	if l := f.parseLimit(field, param); l.err != nil {
+		return fmt.Errorf("参数%q错误:%v", param, l.err)
+	}
+	return nil
+}
+
+//validateDefault 检查默认值能否转换为字段的类型，与bindField一致
+func (f *Form) validateDefault(fm *fieldMeta) error {
+	if fm.defaultValue == "" || fm.kind != plainField {
+		return nil
+	}
+	inputs := []string{fm.defaultValue}
+	if fm.multi {
+		sep := fm.sep
+		if sep == "" {
+			sep = ","
+		}
+		inputs = strings.Split(fm.defaultValue, sep)
+	}
+	v := reflect.New(fm.field.Type).Elem()
+	for _, input := range inputs {
+		if err := f.setValue(fm.field, v, input); err != nil {
+			if re, ok := err.(ruleError); ok && re.err == nil {
+				return fmt.Errorf("%q不能转换为%v", input, fm.field.Type)
+			}
+			return fmt.Errorf("%q不能转换为%v:%v", input, fm.field.Type, err)
+		}
+	}
+	return nil
+}